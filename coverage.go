@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Coverage records which statements of a program ran and which way each cap
+// went. Counts are kept per source line, with a Coverage of its own for
+// each module the program imports.
+type Coverage struct {
+	filename   string
+	lines      []string
+	statements map[int]int     // every statement line, with how often it ran
+	branches   map[int]*[2]int // cap line -> times the cap and nocap branches ran
+
+	main    *Coverage   // the program's coverage, for a module's
+	modules []*Coverage // on the program's, in the order they were imported
+}
+
+func NewCoverage(filename string, code string) *Coverage {
+	c := &Coverage{
+		filename:   filename,
+		lines:      strings.Split(code, "\n"),
+		statements: make(map[int]int),
+		branches:   make(map[int]*[2]int),
+	}
+	// A program that doesn't parse won't run, so there's nothing to record
+	if program, err := parseProgram(code); err == nil {
+		c.collect(program.Statements)
+	}
+	return c
+}
+
+// collect finds every statement and cap so ones that never run still show.
+func (c *Coverage) collect(statements []ASTNode) {
+	for _, stmt := range statements {
+		c.statements[nodeLine(stmt)] += 0
+		switch s := stmt.(type) {
+		case *IfStmt:
+			c.branches[s.Line] = &[2]int{}
+			c.collect(s.ThenBlock)
+			c.collect(s.ElseBlock)
+		case *WhileStmt:
+			c.collect(s.Body)
+		case *ForStmt:
+			c.collect(s.Body)
+		case *SigmaFunc:
+			c.collect(s.Body)
+		case *TryStmt:
+			c.collect(s.Body)
+			c.collect(s.CatchBlock)
+			c.collect(s.FinallyBlock)
+		}
+	}
+}
+
+func (c *Coverage) forModule(module *Interpreter, path string, code string) ExecutionObserver {
+	main := c
+	if c.main != nil {
+		main = c.main
+	}
+	m := NewCoverage(path, code)
+	m.main = main
+	main.modules = append(main.modules, m)
+	return m
+}
+
+func (c *Coverage) Statement(stmt ASTNode, depth int) {
+	c.statements[nodeLine(stmt)]++
+}
+
+func (c *Coverage) Assigned(stmt ASTNode, name string, value interface{}, depth int) {}
+
+func (c *Coverage) Condition(stmt ASTNode, result bool, depth int) {
+	if s, ok := stmt.(*IfStmt); ok {
+		if c.branches[s.Line] == nil {
+			c.branches[s.Line] = &[2]int{}
+		}
+		if result {
+			c.branches[s.Line][0]++
+		} else {
+			c.branches[s.Line][1]++
+		}
+	}
+}
+
+func (c *Coverage) Enter(fn *SigmaFunc, args []interface{}, depth int) {}
+
+func (c *Coverage) Exit(fn *SigmaFunc, result interface{}, depth int) {}
+
+// counts returns how many statement lines and branches there are and how
+// many of each ran.
+func (c *Coverage) counts() (lines, linesHit, branches, branchesHit int) {
+	for _, hits := range c.statements {
+		lines++
+		if hits > 0 {
+			linesHit++
+		}
+	}
+	for _, taken := range c.branches {
+		branches += 2
+		for _, n := range taken {
+			if n > 0 {
+				branchesHit++
+			}
+		}
+	}
+	return
+}
+
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(hit) * 100 / float64(total)
+}
+
+// PrintSummary prints one line of totals for the file and for each module
+// it imported.
+func (c *Coverage) PrintSummary(out io.Writer) {
+	for _, f := range c.files() {
+		lines, linesHit, branches, branchesHit := f.counts()
+		fmt.Fprintf(out, "🧪 %s: %.1f%% of statements (%d/%d), %.1f%% of branches (%d/%d)\n",
+			f.filename, percent(linesHit, lines), linesHit, lines,
+			percent(branchesHit, branches), branchesHit, branches)
+	}
+}
+
+// files returns the program's coverage followed by its modules'.
+func (c *Coverage) files() []*Coverage {
+	return append([]*Coverage{c}, c.modules...)
+}
+
+// WriteListing writes the source with each statement line's run count in
+// front, ##### marking lines that never ran.
+func (c *Coverage) WriteListing(out io.Writer) error {
+	for idx, text := range c.lines {
+		line := idx + 1
+		if line == len(c.lines) && text == "" {
+			break
+		}
+		count := "-"
+		if hits, ok := c.statements[line]; ok {
+			count = "#####"
+			if hits > 0 {
+				count = fmt.Sprint(hits)
+			}
+		}
+		text = strings.TrimRight(text, "\r")
+		if taken, ok := c.branches[line]; ok {
+			text += fmt.Sprintf("  [cap %d, nocap %d]", taken[0], taken[1])
+		}
+		if _, err := fmt.Fprintf(out, "%6s | %4d | %s\n", count, line, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteLcov writes the counts as an lcov tracefile, with a record for the
+// program and one for each module it imported.
+func (c *Coverage) WriteLcov(out io.Writer) error {
+	var b strings.Builder
+	for _, f := range c.files() {
+		f.lcovRecord(&b)
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (c *Coverage) lcovRecord(b *strings.Builder) {
+	fmt.Fprintln(b, "TN:")
+	fmt.Fprintf(b, "SF:%s\n", c.filename)
+
+	var ifLines []int
+	for line := range c.branches {
+		ifLines = append(ifLines, line)
+	}
+	sort.Ints(ifLines)
+	for block, line := range ifLines {
+		for branch, n := range c.branches[line] {
+			taken := fmt.Sprint(n)
+			if c.statements[line] == 0 {
+				taken = "-" // the cap itself never ran
+			}
+			fmt.Fprintf(b, "BRDA:%d,%d,%d,%s\n", line, block, branch, taken)
+		}
+	}
+
+	var lines []int
+	for line := range c.statements {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	for _, line := range lines {
+		fmt.Fprintf(b, "DA:%d,%d\n", line, c.statements[line])
+	}
+
+	total, hit, branches, branchesHit := c.counts()
+	fmt.Fprintf(b, "BRF:%d\nBRH:%d\n", branches, branchesHit)
+	fmt.Fprintf(b, "LF:%d\nLH:%d\n", total, hit)
+	fmt.Fprintln(b, "end_of_record")
+}
+
+// writeCoverage saves lcov.info and an annotated <file>.cov listing in dir
+// for the program and each module it imported.
+func (c *Coverage) writeCoverage(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var lcov strings.Builder
+	if err := c.WriteLcov(&lcov); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lcov.info"), []byte(lcov.String()), 0644); err != nil {
+		return err
+	}
+	for _, f := range c.files() {
+		var listing strings.Builder
+		if err := f.WriteListing(&listing); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(f.filename)+".cov"), []byte(listing.String()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
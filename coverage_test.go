@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+const coverageProgram = `sigma sign(n) {
+    cap (n < 0) {
+        alpha "negative" ohio
+    }
+    nocap {
+        alpha "positive" ohio
+    }
+}
+sigma unused() {
+    cap (true) {
+        gyatt "never" ohio
+    }
+}
+gyatt sign(1) ohio
+gyatt sign(2) ohio
+`
+
+func coverageRun() *Coverage {
+	coverage := NewCoverage("sign.skibidi", coverageProgram)
+	runObserved(coverageProgram, func(interpreter *Interpreter) {
+		interpreter.observers = append(interpreter.observers, coverage)
+	})
+	return coverage
+}
+
+// TestCoverageLcov checks the tracefile, where a cap that never ran has
+// "-" for both branches.
+func TestCoverageLcov(t *testing.T) {
+	var lcov bytes.Buffer
+	if err := coverageRun().WriteLcov(&lcov); err != nil {
+		t.Fatal(err)
+	}
+	want := `TN:
+SF:sign.skibidi
+BRDA:2,0,0,0
+BRDA:2,0,1,2
+BRDA:10,1,0,-
+BRDA:10,1,1,-
+DA:1,1
+DA:2,2
+DA:3,0
+DA:6,2
+DA:9,1
+DA:10,0
+DA:11,0
+DA:14,1
+DA:15,1
+BRF:4
+BRH:1
+LF:9
+LH:6
+end_of_record
+`
+	if lcov.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", lcov.String(), want)
+	}
+}
+
+func TestCoverageListing(t *testing.T) {
+	var listing bytes.Buffer
+	if err := coverageRun().WriteListing(&listing); err != nil {
+		t.Fatal(err)
+	}
+	want := `     1 |    1 | sigma sign(n) {
+     2 |    2 |     cap (n < 0) {  [cap 0, nocap 2]
+ ##### |    3 |         alpha "negative" ohio
+     - |    4 |     }
+     - |    5 |     nocap {
+     2 |    6 |         alpha "positive" ohio
+     - |    7 |     }
+     - |    8 | }
+     1 |    9 | sigma unused() {
+ ##### |   10 |     cap (true) {  [cap 0, nocap 0]
+ ##### |   11 |         gyatt "never" ohio
+     - |   12 |     }
+     - |   13 | }
+     1 |   14 | gyatt sign(1) ohio
+     1 |   15 | gyatt sign(2) ohio
+`
+	if listing.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", listing.String(), want)
+	}
+}
+
+func TestCoverageSummary(t *testing.T) {
+	var summary bytes.Buffer
+	coverageRun().PrintSummary(&summary)
+	want := "🧪 sign.skibidi: 66.7% of statements (6/9), 25.0% of branches (1/4)\n"
+	if summary.String() != want {
+		t.Errorf("got %q, want %q", summary.String(), want)
+	}
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// dapThreadID is the only thread a Skibidi program has.
+const dapThreadID = 1
+
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type dapResponse struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type dapEvent struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// dapFrame is one sigma call (or the top level) in a paused program, or in
+// a module it imported.
+type dapFrame struct {
+	name        string
+	line        int
+	path        string
+	interpreter *Interpreter // the program's, or the module's the frame is in
+	locals      []*callFrame // the function's frame and its block scopes, innermost first
+	scopes      int          // how much of the interpreter's stack the frame sees
+}
+
+// DAPServer lets editors debug a Skibidi program over the Debug Adapter
+// Protocol. The program runs on its own goroutine and blocks in the
+// debugger's pause handler while the client inspects it.
+type DAPServer struct {
+	in      *bufio.Reader
+	out     io.Writer
+	writeMu sync.Mutex
+	seq     int
+
+	mu          sync.Mutex // guards the fields below
+	breakpoints []int
+	launched    bool
+	configured  bool
+	started     bool
+	paused      bool
+	stopReason  string
+	stopDepth   int
+	frames      []dapFrame
+	varRefs     map[int][]*callFrame
+
+	source      string
+	program     *Program
+	interpreter *Interpreter
+	debugger    *Debugger
+	resumeCh    chan struct{}
+	done        chan struct{}
+}
+
+func NewDAPServer(in io.Reader, out io.Writer) *DAPServer {
+	return &DAPServer{
+		in:       bufio.NewReader(in),
+		out:      out,
+		resumeCh: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *DAPServer) send(msg interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.seq++
+	switch m := msg.(type) {
+	case *dapResponse:
+		m.Seq = s.seq
+	case *dapEvent:
+		m.Seq = s.seq
+	}
+	writeFrame(s.out, msg)
+}
+
+func (s *DAPServer) event(name string, body interface{}) {
+	s.send(&dapEvent{Type: "event", Event: name, Body: body})
+}
+
+// dapOutput turns everything the program prints into output events.
+type dapOutput struct {
+	server   *DAPServer
+	category string
+}
+
+func (o dapOutput) Write(p []byte) (int, error) {
+	o.server.event("output", map[string]string{"category": o.category, "output": string(p)})
+	return len(p), nil
+}
+
+// Serve handles requests until the client disconnects or closes the stream.
+func (s *DAPServer) Serve() error {
+	for {
+		body, err := readFrame(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		req := &dapRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			return err
+		}
+
+		result, err := s.handle(req)
+		resp := &dapResponse{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: result}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		s.send(resp)
+
+		// Anything that depends on the client having seen the response
+		// happens afterwards
+		switch req.Command {
+		case "initialize":
+			s.event("initialized", nil)
+		case "launch", "configurationDone":
+			s.maybeStart()
+		case "continue", "next", "stepIn", "stepOut":
+			if err == nil {
+				s.resumeCh <- struct{}{}
+			}
+		case "disconnect", "terminate":
+			s.stop()
+			if req.Command == "disconnect" {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *DAPServer) handle(req *dapRequest) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+
+	case "launch":
+		var args struct {
+			Program     string `json:"program"`
+			StopOnEntry bool   `json:"stopOnEntry"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args.Program, args.StopOnEntry)
+
+	case "setBreakpoints":
+		var args struct {
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.breakpoints = nil
+		verified := []map[string]interface{}{}
+		for _, bp := range args.Breakpoints {
+			s.breakpoints = append(s.breakpoints, bp.Line)
+			verified = append(verified, map[string]interface{}{"verified": true, "line": bp.Line})
+		}
+		s.applyBreakpoints()
+		s.mu.Unlock()
+		return map[string]interface{}{"breakpoints": verified}, nil
+
+	case "configurationDone":
+		s.mu.Lock()
+		s.configured = true
+		s.mu.Unlock()
+		return nil, nil
+
+	case "threads":
+		return map[string]interface{}{
+			"threads": []map[string]interface{}{{"id": dapThreadID, "name": "main"}},
+		}, nil
+
+	case "stackTrace":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		frames := []map[string]interface{}{}
+		for idx, frame := range s.frames {
+			frames = append(frames, map[string]interface{}{
+				"id":     idx,
+				"name":   frame.name,
+				"line":   frame.line,
+				"column": 1,
+				"source": map[string]string{"name": filepath.Base(frame.path), "path": frame.path},
+			})
+		}
+		return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+
+	case "scopes":
+		var args struct {
+			FrameID int `json:"frameId"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if args.FrameID < 0 || args.FrameID >= len(s.frames) {
+			return nil, fmt.Errorf("unknown frame %d", args.FrameID)
+		}
+		// Variable references are 1 for the program's globals and 2+id for
+		// each frame's locals; a module's globals come after those
+		globals := 1
+		if s.frames[args.FrameID].interpreter != s.interpreter {
+			globals = 2 + len(s.frames) + args.FrameID
+		}
+		return map[string]interface{}{
+			"scopes": []map[string]interface{}{
+				{"name": "Locals", "variablesReference": 2 + args.FrameID, "expensive": false},
+				{"name": "Globals", "variablesReference": globals, "expensive": false},
+			},
+		}, nil
+
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		frames, ok := s.varRefs[args.VariablesReference]
+		if !ok {
+			return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+		}
+		return map[string]interface{}{"variables": s.variables(frames)}, nil
+
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+			FrameID    *int   `json:"frameId"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if !s.isPaused() {
+			return nil, fmt.Errorf("the program is not paused")
+		}
+		// Without a frame the expression sees only the program's globals
+		s.mu.Lock()
+		interpreter, scopes := s.interpreter, 1
+		if args.FrameID != nil {
+			if *args.FrameID < 0 || *args.FrameID >= len(s.frames) {
+				s.mu.Unlock()
+				return nil, fmt.Errorf("unknown frame %d", *args.FrameID)
+			}
+			frame := s.frames[*args.FrameID]
+			interpreter, scopes = frame.interpreter, frame.scopes
+		}
+		s.mu.Unlock()
+		result, err := s.debugger.evaluateIn(interpreter, scopes, args.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"result": result, "variablesReference": 0}, nil
+
+	case "continue":
+		return map[string]interface{}{"allThreadsContinued": true}, s.prepareResume(stepRun, "breakpoint")
+	case "next":
+		return nil, s.prepareResume(stepOver, "step")
+	case "stepIn":
+		return nil, s.prepareResume(stepIn, "step")
+	case "stepOut":
+		return nil, s.prepareResume(stepOut, "step")
+	case "pause":
+		if s.debugger != nil {
+			s.mu.Lock()
+			s.stopReason = "pause"
+			s.mu.Unlock()
+			s.debugger.resume(stepIn, 0)
+		}
+		return nil, nil
+
+	case "disconnect", "terminate":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported request %s", req.Command)
+}
+
+func (s *DAPServer) launch(path string, stopOnEntry bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading file '%s': %v", path, err)
+	}
+	program, err := parseProgram(string(content))
+	if err != nil {
+		return fmt.Errorf("Skibidi Error: %v", err)
+	}
+
+	interpreter := NewInterpreter()
+	interpreter.output = dapOutput{server: s, category: "stdout"}
+	interpreter.inputScanner = bufio.NewScanner(strings.NewReader(""))
+	interpreter.SetFile(path)
+	debugger := NewDebugger(interpreter, path, string(content))
+	debugger.out = interpreter.output
+	debugger.pause = s.pause
+	if stopOnEntry {
+		debugger.resume(stepIn, 0)
+	} else {
+		debugger.resume(stepRun, 0)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.source = path
+	s.program = program
+	s.interpreter = interpreter
+	s.debugger = debugger
+	s.launched = true
+	s.stopReason = "breakpoint"
+	if stopOnEntry {
+		s.stopReason = "entry"
+	}
+	s.applyBreakpoints()
+	return nil
+}
+
+// applyBreakpoints copies the client's breakpoints into the debugger. The
+// caller holds s.mu.
+func (s *DAPServer) applyBreakpoints() {
+	if s.debugger == nil {
+		return
+	}
+	s.debugger.mu.Lock()
+	s.debugger.breakpoints = make(map[int]bool)
+	for _, line := range s.breakpoints {
+		s.debugger.breakpoints[line] = true
+	}
+	s.debugger.mu.Unlock()
+}
+
+// maybeStart runs the program once it is launched and configured.
+func (s *DAPServer) maybeStart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.launched || !s.configured || s.started {
+		return
+	}
+	s.started = true
+
+	go func() {
+		exitCode := 0
+		defer func() {
+			if r := recover(); r != nil {
+				if exit, ok := r.(*ExitError); ok {
+					exitCode = exit.Code
+				} else if _, quit := r.(debugQuit); !quit {
+					dapOutput{server: s, category: "stderr"}.Write([]byte(fmt.Sprintf("Skibidi Error: %v\n", r)))
+					exitCode = 1
+				}
+			}
+			s.event("exited", map[string]int{"exitCode": exitCode})
+			s.event("terminated", nil)
+			close(s.done)
+		}()
+		s.interpreter.Execute(s.program)
+	}()
+}
+
+// pause runs on the program's goroutine: it snapshots the stack, tells the
+// client, and waits for a resume request.
+func (s *DAPServer) pause(line, depth int) {
+	s.mu.Lock()
+	s.paused = true
+	s.stopDepth = depth
+	s.snapshot(line)
+	reason := s.stopReason
+	s.debugger.mu.Lock()
+	if s.debugger.breakpoints[line] && reason != "entry" {
+		reason = "breakpoint"
+	}
+	s.debugger.mu.Unlock()
+	s.mu.Unlock()
+
+	s.event("stopped", map[string]interface{}{"reason": reason, "threadId": dapThreadID, "allThreadsStopped": true})
+	<-s.resumeCh
+}
+
+func (s *DAPServer) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// prepareResume sets up the next stop; Serve wakes the program after the
+// response has been sent.
+func (s *DAPServer) prepareResume(mode stepMode, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		return fmt.Errorf("the program is not paused")
+	}
+	s.paused = false
+	s.stopReason = reason
+	s.debugger.resume(mode, s.stopDepth)
+	return nil
+}
+
+// stop abandons a running program and waits for it to finish.
+func (s *DAPServer) stop() {
+	s.mu.Lock()
+	started, paused := s.started, s.paused
+	s.paused = false
+	if s.debugger != nil {
+		s.debugger.mu.Lock()
+		s.debugger.quitting = true
+		s.debugger.mu.Unlock()
+	}
+	s.mu.Unlock()
+
+	if !started {
+		return
+	}
+	if paused {
+		s.resumeCh <- struct{}{}
+	}
+	<-s.done
+}
+
+// snapshot records the stack frames for stackTrace, scopes and variables,
+// from the module paused in, if any, out to the program. The caller holds
+// s.mu.
+func (s *DAPServer) snapshot(line int) {
+	s.frames = nil
+	s.varRefs = map[int][]*callFrame{1: {s.interpreter.callStack[0]}}
+
+	for i := s.debugger.running(); i != nil; i = i.caller {
+		stack := i.callStack
+		path := i.path
+		if i == s.interpreter {
+			path = s.source
+		}
+		current := dapFrame{line: line, path: path, interpreter: i, scopes: len(stack)}
+		called := false
+		for idx := len(stack) - 1; idx > 0; idx-- {
+			frame := stack[idx]
+			current.locals = append(current.locals, frame)
+			if frame.function != nil {
+				current.name = frame.function.Name
+				s.frames = append(s.frames, current)
+				current = dapFrame{line: frame.callLine, path: path, interpreter: i, scopes: idx}
+				called = true
+			}
+		}
+		switch {
+		case i.caller == nil:
+			current.name = "<main>"
+			s.frames = append(s.frames, current)
+		case !called:
+			// The module's top level, running as it is imported
+			current.name = "<import " + filepath.Base(i.path) + ">"
+			s.frames = append(s.frames, current)
+			line = i.caller.line
+		default:
+			// The sigma was called from the program's line
+			line = current.line
+		}
+	}
+
+	for idx, frame := range s.frames {
+		s.varRefs[2+idx] = frame.locals
+		if frame.interpreter != s.interpreter {
+			s.varRefs[2+len(s.frames)+idx] = []*callFrame{frame.interpreter.callStack[0]}
+		}
+	}
+}
+
+// variables lists the names visible in frames, letting inner scopes shadow
+// outer ones.
+func (s *DAPServer) variables(frames []*callFrame) []map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, frame := range frames {
+		for name, value := range frame.variables() {
+			if _, shadowed := values[name]; !shadowed {
+				values[name] = value
+			}
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := []map[string]interface{}{}
+	for _, name := range names {
+		vars = append(vars, map[string]interface{}{
+			"name":               name,
+			"value":              s.interpreter.toString(values[name]),
+			"variablesReference": 0,
+		})
+	}
+	return vars
+}
+
+// runDAP serves the debug adapter on stdin and stdout.
+func runDAP() {
+	server := NewDAPServer(os.Stdin, os.Stdout)
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "skibidi dap: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dapClient drives a DAPServer over pipes the way an editor would.
+type dapClient struct {
+	t       *testing.T
+	toSrv   *io.PipeWriter
+	fromSrv *bufio.Reader
+	seq     int
+	events  []map[string]interface{} // events read while waiting for responses
+	done    chan error
+}
+
+func startDAP(t *testing.T) *dapClient {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	c := &dapClient{t: t, toSrv: clientOut, fromSrv: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewDAPServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+	}()
+	return c
+}
+
+func (c *dapClient) read() map[string]interface{} {
+	c.t.Helper()
+	type result struct {
+		body []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		body, err := readFrame(c.fromSrv)
+		ch <- result{body, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			c.t.Fatalf("reading message: %v", r.err)
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(r.body, &msg); err != nil {
+			c.t.Fatal(err)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the debug adapter")
+	}
+	return nil
+}
+
+// request sends a request and returns the body of its response, failing
+// the test if the request did.
+func (c *dapClient) request(command string, args interface{}) map[string]interface{} {
+	c.t.Helper()
+	msg := c.call(command, args)
+	if msg["success"] != true {
+		c.t.Fatalf("%s failed: %v", command, msg["message"])
+	}
+	body, _ := msg["body"].(map[string]interface{})
+	return body
+}
+
+// call sends a request and returns its response, queueing any events that
+// arrive first.
+func (c *dapClient) call(command string, args interface{}) map[string]interface{} {
+	c.t.Helper()
+	c.seq++
+	if err := writeFrame(c.toSrv, map[string]interface{}{
+		"seq": c.seq, "type": "request", "command": command, "arguments": args,
+	}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg["type"] == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg["request_seq"] != float64(c.seq) {
+			c.t.Fatalf("response to %v while waiting for %s", msg["request_seq"], command)
+		}
+		return msg
+	}
+}
+
+// waitEvent returns the next event with the given name.
+func (c *dapClient) waitEvent(name string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		var msg map[string]interface{}
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.read()
+		}
+		if msg["event"] == name {
+			body, _ := msg["body"].(map[string]interface{})
+			return body
+		}
+	}
+}
+
+// stack returns the names and lines of the paused program's frames.
+func (c *dapClient) stack() []string {
+	c.t.Helper()
+	var frames []string
+	for _, f := range c.request("stackTrace", map[string]int{"threadId": dapThreadID})["stackFrames"].([]interface{}) {
+		frame := f.(map[string]interface{})
+		frames = append(frames, fmt.Sprintf("%s:%v", frame["name"], frame["line"]))
+	}
+	return frames
+}
+
+// locals returns the Locals scope of frame as name=value pairs.
+func (c *dapClient) locals(frame int) map[string]string {
+	c.t.Helper()
+	scopes := c.request("scopes", map[string]int{"frameId": frame})["scopes"].([]interface{})
+	ref := scopes[0].(map[string]interface{})["variablesReference"]
+	vars := map[string]string{}
+	for _, v := range c.request("variables", map[string]interface{}{"variablesReference": ref})["variables"].([]interface{}) {
+		variable := v.(map[string]interface{})
+		vars[variable["name"].(string)] = variable["value"].(string)
+	}
+	return vars
+}
+
+const dapSample = `sigma double(n) {
+    skibidi result rizz n * 2 ohio
+    alpha result ohio
+}
+skibidi x rizz 5 ohio
+skibidi y rizz beta double(x) ohio
+gyatt y ohio
+`
+
+func TestDAPSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.skibidi")
+	if err := os.WriteFile(path, []byte(dapSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := startDAP(t)
+	c.request("initialize", map[string]string{"adapterID": "skibidi"})
+	c.waitEvent("initialized")
+	c.request("launch", map[string]interface{}{"program": path})
+	bps := c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": path},
+		"breakpoints": []map[string]int{{"line": 6}},
+	})["breakpoints"].([]interface{})
+	if len(bps) != 1 || bps[0].(map[string]interface{})["verified"] != true {
+		t.Fatalf("breakpoint not verified: %v", bps)
+	}
+	c.request("configurationDone", nil)
+
+	if stopped := c.waitEvent("stopped"); stopped["reason"] != "breakpoint" {
+		t.Errorf("stopped for %v, want breakpoint", stopped["reason"])
+	}
+	if got := strings.Join(c.stack(), " "); got != "<main>:6" {
+		t.Errorf("stack = %s, want <main>:6", got)
+	}
+	c.request("threads", nil)
+	globals := c.request("variables", map[string]int{"variablesReference": 1})["variables"].([]interface{})
+	if len(globals) != 1 || globals[0].(map[string]interface{})["value"] != "5" {
+		t.Errorf("globals = %v, want x = 5", globals)
+	}
+
+	// Step into double and look at its frame
+	c.request("stepIn", map[string]int{"threadId": dapThreadID})
+	c.waitEvent("stopped")
+	if got := strings.Join(c.stack(), " "); got != "double:2 <main>:6" {
+		t.Errorf("stack = %s, want double:2 <main>:6", got)
+	}
+	c.request("next", map[string]int{"threadId": dapThreadID})
+	c.waitEvent("stopped")
+	if vars := c.locals(0); vars["n"] != "5" || vars["result"] != "10" {
+		t.Errorf("locals = %v, want n = 5, result = 10", vars)
+	}
+	if result := c.request("evaluate", map[string]interface{}{"expression": "result + n", "frameId": 0})["result"]; result != "15" {
+		t.Errorf("evaluate = %v, want 15", result)
+	}
+
+	// Step out lands back on the top level after the call
+	c.request("stepOut", map[string]int{"threadId": dapThreadID})
+	c.waitEvent("stopped")
+	if got := strings.Join(c.stack(), " "); got != "<main>:7" {
+		t.Errorf("stack = %s, want <main>:7", got)
+	}
+
+	c.request("continue", map[string]int{"threadId": dapThreadID})
+	if output := c.waitEvent("output"); output["output"] != "10\n" {
+		t.Errorf("output = %q, want 10", output["output"])
+	}
+	if exited := c.waitEvent("exited"); exited["exitCode"] != float64(0) {
+		t.Errorf("exit code = %v, want 0", exited["exitCode"])
+	}
+	c.waitEvent("terminated")
+
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Fatalf("server exited with %v", err)
+	}
+}
+
+// TestDAPSteppingIntoModule checks that stepping into a module's sigma
+// shows the module's frame, source and globals.
+func TestDAPSteppingIntoModule(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("test", "modules.skibidi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := startDAP(t)
+	c.request("initialize", map[string]string{"adapterID": "skibidi"})
+	c.waitEvent("initialized")
+	c.request("launch", map[string]interface{}{"program": path})
+	c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": path},
+		"breakpoints": []map[string]int{{"line": 5}},
+	})
+	c.request("configurationDone", nil)
+	c.waitEvent("stopped")
+
+	c.request("stepIn", map[string]int{"threadId": dapThreadID})
+	c.waitEvent("stopped")
+	if got := strings.Join(c.stack(), " "); got != "area:6 <main>:5" {
+		t.Errorf("stack = %s, want area:6 <main>:5", got)
+	}
+	frame := c.request("stackTrace", map[string]int{"threadId": dapThreadID})["stackFrames"].([]interface{})[0].(map[string]interface{})
+	if source := frame["source"].(map[string]interface{})["name"]; source != "shapes.skibidi" {
+		t.Errorf("frame source = %v, want shapes.skibidi", source)
+	}
+	scopes := c.request("scopes", map[string]int{"frameId": 0})["scopes"].([]interface{})
+	ref := scopes[1].(map[string]interface{})["variablesReference"]
+	globals := c.request("variables", map[string]interface{}{"variablesReference": ref})["variables"].([]interface{})
+	if len(globals) != 1 || globals[0].(map[string]interface{})["name"] != "unit" {
+		t.Errorf("module globals = %v, want unit", globals)
+	}
+
+	c.request("continue", map[string]int{"threadId": dapThreadID})
+	c.waitEvent("terminated")
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Fatalf("server exited with %v", err)
+	}
+}
+
+// TestDAPEvaluateInFrame checks that evaluate sees the variables of the
+// frame it is given, and only the globals without one.
+func TestDAPEvaluateInFrame(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frames.skibidi")
+	code := `sigma inner(n) {
+    skibidi v rizz "inner" ohio
+    alpha v ohio
+}
+sigma outer() {
+    skibidi v rizz "outer" ohio
+    alpha inner(1) ohio
+}
+skibidi v rizz "global" ohio
+gyatt outer() ohio
+`
+	if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := startDAP(t)
+	c.request("initialize", map[string]string{"adapterID": "skibidi"})
+	c.waitEvent("initialized")
+	c.request("launch", map[string]interface{}{"program": path})
+	c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": path},
+		"breakpoints": []map[string]int{{"line": 3}},
+	})
+	c.request("configurationDone", nil)
+	c.waitEvent("stopped")
+	if got := strings.Join(c.stack(), " "); got != "inner:3 outer:7 <main>:10" {
+		t.Fatalf("stack = %s, want inner:3 outer:7 <main>:10", got)
+	}
+
+	tests := []struct {
+		expression string
+		frame      interface{} // nil for no frameId
+		want       string      // the result, or the error message
+	}{
+		{"v + n", 0, "inner1"},
+		{"v", 1, "outer"},
+		{"v", 2, "global"},
+		{"v", nil, "global"},
+		{"n", 1, "Undefined variable: n at line 1"},
+		{"len(v)", 2, "6"},
+		{"v", 3, "unknown frame 3"},
+	}
+	for _, test := range tests {
+		args := map[string]interface{}{"expression": test.expression}
+		if test.frame != nil {
+			args["frameId"] = test.frame
+		}
+		resp := c.call("evaluate", args)
+		got, _ := resp["message"].(string)
+		if resp["success"] == true {
+			got, _ = resp["body"].(map[string]interface{})["result"].(string)
+		}
+		if got != test.want {
+			t.Errorf("evaluate %q in frame %v = %q, want %q", test.expression, test.frame, got, test.want)
+		}
+	}
+	// Evaluating in an outer frame leaves the paused frame as it was
+	if vars := c.locals(0); vars["v"] != "inner" || vars["n"] != "1" {
+		t.Errorf("locals = %v, want v = inner, n = 1", vars)
+	}
+
+	c.request("continue", map[string]int{"threadId": dapThreadID})
+	if output := c.waitEvent("output"); output["output"] != "inner\n" {
+		t.Errorf("output = %q, want inner", output["output"])
+	}
+	c.waitEvent("terminated")
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Fatalf("server exited with %v", err)
+	}
+}
+
+// TestDAPDisconnectStopsLoop checks that disconnecting stops a program
+// busy in a loop that never runs a statement.
+func TestDAPDisconnectStopsLoop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spin.skibidi")
+	if err := os.WriteFile(path, []byte("bussin (true) {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := startDAP(t)
+	c.request("initialize", map[string]string{"adapterID": "skibidi"})
+	c.waitEvent("initialized")
+	c.request("launch", map[string]interface{}{"program": path})
+	c.request("configurationDone", nil)
+
+	c.request("disconnect", nil)
+	c.waitEvent("terminated")
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Fatalf("server exited with %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("disconnect did not stop the program")
+	}
+}
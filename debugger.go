@@ -0,0 +1,388 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type stepMode int
+
+const (
+	stepRun  stepMode = iota // run until a breakpoint
+	stepIn                   // pause at the very next statement
+	stepOver                 // pause at the next statement outside deeper calls
+	stepOut                  // pause once the current function returns
+)
+
+// debugQuit is panicked to abandon the program when the user quits.
+type debugQuit struct{}
+
+// Debugger pauses an Interpreter at breakpoints and steps through it,
+// stepping into the modules it imports too. By default it takes commands
+// from a terminal prompt; the DAP server swaps in its own pause handler.
+// Breakpoints are lines of the program's own file.
+type Debugger struct {
+	interpreter *Interpreter
+	filename    string
+	lines       []string
+	modules     map[string][]string // source lines of modules it has paused in
+	out         io.Writer
+	pause       func(line, depth int) // called when execution stops
+
+	mu          sync.Mutex   // guards the fields below when driven from another goroutine
+	current     *Interpreter // running the statement paused at: the program's or a module's
+	breakpoints map[int]bool
+	mode        stepMode
+	stepDepth   int
+	evaluating  bool // ignore statements run by print expressions
+	quitting    bool
+}
+
+func NewDebugger(interpreter *Interpreter, filename string, code string) *Debugger {
+	d := &Debugger{
+		interpreter: interpreter,
+		filename:    filename,
+		lines:       strings.Split(code, "\n"),
+		modules:     make(map[string][]string),
+		out:         os.Stdout,
+		breakpoints: make(map[int]bool),
+		mode:        stepIn,
+		current:     interpreter,
+	}
+	d.pause = d.prompt
+	interpreter.beforeStatement = d.beforeStatement
+	interpreter.interrupt = d.interrupt
+	return d
+}
+
+// interrupt abandons the program once the user has quit.
+func (d *Debugger) interrupt(i *Interpreter) {
+	d.mu.Lock()
+	quitting := d.quitting
+	d.mu.Unlock()
+	if quitting {
+		panic(debugQuit{})
+	}
+}
+
+func (d *Debugger) beforeStatement(i *Interpreter, stmt ASTNode) {
+	d.interrupt(i)
+	line := nodeLine(stmt)
+	depth := i.callDepth()
+
+	d.mu.Lock()
+	pause := false
+	if !d.evaluating {
+		pause = i == d.interpreter && d.breakpoints[line]
+		switch d.mode {
+		case stepIn:
+			pause = true
+		case stepOver:
+			pause = pause || depth <= d.stepDepth
+		case stepOut:
+			pause = pause || depth < d.stepDepth
+		}
+	}
+	if pause {
+		d.current = i
+	}
+	d.mu.Unlock()
+
+	if pause {
+		d.pause(line, depth)
+	}
+}
+
+// running returns the interpreter of the statement paused at.
+func (d *Debugger) running() *Interpreter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.current
+}
+
+// file names the file i is running, for showing locations.
+func (d *Debugger) file(i *Interpreter) string {
+	if i == d.interpreter {
+		return d.filename
+	}
+	return filepath.Base(i.path)
+}
+
+// source returns the lines of the file i is running.
+func (d *Debugger) source(i *Interpreter) []string {
+	if i == d.interpreter {
+		return d.lines
+	}
+	lines, ok := d.modules[i.path]
+	if !ok {
+		content, _ := os.ReadFile(i.path)
+		lines = strings.Split(string(content), "\n")
+		d.modules[i.path] = lines
+	}
+	return lines
+}
+
+// resume sets how far the program runs before pausing again.
+func (d *Debugger) resume(mode stepMode, depth int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mode = mode
+	d.stepDepth = depth
+}
+
+func (d *Debugger) setBreakpoint(line int, set bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if set {
+		d.breakpoints[line] = true
+	} else {
+		delete(d.breakpoints, line)
+	}
+}
+
+// prompt shows where execution stopped and handles commands until one of
+// them resumes the program.
+func (d *Debugger) prompt(line, depth int) {
+	fmt.Fprintf(d.out, "⏸  %s:%d\n", d.file(d.running()), line)
+	d.showLine(line, true)
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+		if !d.interpreter.inputScanner.Scan() {
+			fmt.Fprintln(d.out)
+			panic(debugQuit{})
+		}
+		fields := strings.Fields(d.interpreter.inputScanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(d.interpreter.inputScanner.Text()), fields[0]))
+
+		switch fields[0] {
+		case "c", "continue":
+			d.resume(stepRun, depth)
+			return
+		case "s", "step":
+			d.resume(stepIn, depth)
+			return
+		case "n", "next":
+			d.resume(stepOver, depth)
+			return
+		case "o", "out", "finish":
+			d.resume(stepOut, depth)
+			return
+		case "b", "break":
+			if n, ok := d.parseLine(arg); ok {
+				d.setBreakpoint(n, true)
+				fmt.Fprintf(d.out, "Breakpoint set at %s:%d\n", d.filename, n)
+			}
+		case "d", "delete":
+			if n, ok := d.parseLine(arg); ok {
+				d.setBreakpoint(n, false)
+				fmt.Fprintf(d.out, "Breakpoint cleared at %s:%d\n", d.filename, n)
+			}
+		case "breaks":
+			d.listBreakpoints()
+		case "p", "print":
+			if value, err := d.evaluate(arg); err != nil {
+				fmt.Fprintf(d.out, "Skibidi Error: %v\n", err)
+			} else {
+				fmt.Fprintln(d.out, value)
+			}
+		case "v", "vars":
+			d.printVars()
+		case "bt", "where":
+			d.backtrace(line)
+		case "l", "list":
+			for n := line - 3; n <= line+3; n++ {
+				d.showLine(n, n == line)
+			}
+		case "h", "help":
+			d.help()
+		case "q", "quit":
+			panic(debugQuit{})
+		default:
+			fmt.Fprintf(d.out, "Unknown command %q. Type help for commands.\n", fields[0])
+		}
+	}
+}
+
+func (d *Debugger) parseLine(arg string) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(d.lines) {
+		fmt.Fprintf(d.out, "Invalid line number %q\n", arg)
+		return 0, false
+	}
+	return n, true
+}
+
+// showLine shows line n of the file paused in.
+func (d *Debugger) showLine(n int, current bool) {
+	running := d.running()
+	lines := d.source(running)
+	if n < 1 || n > len(lines) {
+		return
+	}
+	marker := " "
+	if current {
+		marker = ">"
+	} else if running == d.interpreter && d.breakpoints[n] {
+		marker = "*"
+	}
+	fmt.Fprintf(d.out, "%s %4d | %s\n", marker, n, strings.TrimRight(lines[n-1], "\r"))
+}
+
+func (d *Debugger) listBreakpoints() {
+	if len(d.breakpoints) == 0 {
+		fmt.Fprintln(d.out, "No breakpoints")
+		return
+	}
+	var lines []int
+	for n := range d.breakpoints {
+		lines = append(lines, n)
+	}
+	sort.Ints(lines)
+	for _, n := range lines {
+		fmt.Fprintf(d.out, "  %s:%d\n", d.filename, n)
+	}
+}
+
+// evaluate runs an expression in the paused program's scope and returns
+// its value as a string.
+func (d *Debugger) evaluate(expr string) (string, error) {
+	running := d.running()
+	return d.evaluateIn(running, len(running.callStack), expr)
+}
+
+// evaluateIn runs an expression in i seeing only the first scopes frames
+// of its stack, which is how a caller further out sees the program.
+func (d *Debugger) evaluateIn(i *Interpreter, scopes int, expr string) (result string, err error) {
+	if strings.TrimSpace(expr) == "" {
+		return "", fmt.Errorf("usage: print <expression>")
+	}
+	d.mu.Lock()
+	d.evaluating = true
+	d.mu.Unlock()
+
+	// Calls push scopes onto a copy, so the frames above stay as they were
+	stack := i.callStack
+	i.callStack = append([]*callFrame(nil), stack[:scopes]...)
+	defer func() {
+		i.callStack = stack
+		d.mu.Lock()
+		d.evaluating = false
+		d.mu.Unlock()
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	parser := NewParser(NewLexer(expr))
+	node := parser.parseExpression()
+	if parser.currentToken.Type != EOF {
+		panic(fmt.Sprintf("Unexpected token %s", parser.currentToken.Value))
+	}
+	return i.toString(i.evaluateExpression(node)), nil
+}
+
+// printVars lists the variables in every frame, innermost first, going on
+// to the program's frames when paused in a module.
+func (d *Debugger) printVars() {
+	for i := d.running(); i != nil; i = i.caller {
+		stack := i.callStack
+		for idx := len(stack) - 1; idx >= 0; idx-- {
+			frame := stack[idx]
+			label := "block"
+			switch {
+			case idx == 0 && i != d.interpreter:
+				label = "globals of " + d.file(i)
+			case idx == 0:
+				label = "globals"
+			case frame.function != nil:
+				label = "sigma " + frame.function.Name
+			}
+			fmt.Fprintf(d.out, "[%d] %s\n", idx, label)
+			vars := frame.variables()
+			for _, name := range sortedKeys(vars) {
+				fmt.Fprintf(d.out, "    %s = %s\n", name, i.toString(vars[name]))
+			}
+		}
+	}
+}
+
+// backtrace prints the sigma calls that led to line, innermost first. A
+// module's code is followed by the line of the program that called or
+// imported it.
+func (d *Debugger) backtrace(line int) {
+	for i := d.running(); i != nil; i = i.caller {
+		called := false
+		stack := i.callStack
+		for idx := len(stack) - 1; idx > 0; idx-- {
+			frame := stack[idx]
+			if frame.function == nil {
+				continue
+			}
+			fmt.Fprintf(d.out, "  %s at %s:%d\n", functionSignature(frame.function), d.file(i), line)
+			line = frame.callLine
+			called = true
+		}
+		switch {
+		case i.caller == nil:
+			fmt.Fprintf(d.out, "  <main> at %s:%d\n", d.file(i), line)
+		case !called:
+			// The module's top level, running as it is imported
+			fmt.Fprintf(d.out, "  <import> at %s:%d\n", d.file(i), line)
+			line = i.caller.line
+		}
+	}
+}
+
+func (d *Debugger) help() {
+	fmt.Fprintln(d.out, "Debugger commands:")
+	fmt.Fprintln(d.out, "  break <line>, b    set a breakpoint")
+	fmt.Fprintln(d.out, "  delete <line>, d   clear a breakpoint")
+	fmt.Fprintln(d.out, "  breaks             list breakpoints")
+	fmt.Fprintln(d.out, "  continue, c        run to the next breakpoint")
+	fmt.Fprintln(d.out, "  next, n            step over beta calls")
+	fmt.Fprintln(d.out, "  step, s            step into beta calls")
+	fmt.Fprintln(d.out, "  out, o             run until the current sigma returns")
+	fmt.Fprintln(d.out, "  print <expr>, p    evaluate an expression")
+	fmt.Fprintln(d.out, "  vars, v            show variables in every frame")
+	fmt.Fprintln(d.out, "  where, bt          show the call stack")
+	fmt.Fprintln(d.out, "  list, l            show source around the current line")
+	fmt.Fprintln(d.out, "  quit, q            stop the program")
+}
+
+// runDebug runs a program under the interactive debugger, paused before
+// its first statement.
+func runDebug(filename string, code string) {
+	interpreter := NewInterpreter()
+	interpreter.SetFile(filename)
+	debugger := NewDebugger(interpreter, filename, code)
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(debugQuit); ok {
+				fmt.Fprintln(debugger.out, "👋 Debugging stopped")
+				return
+			}
+			if exit, ok := r.(*ExitError); ok {
+				fmt.Fprintf(debugger.out, "✅ Program exited with status %d\n", exit.Code)
+				return
+			}
+			fmt.Printf("Skibidi Error: %v\n", r)
+		}
+	}()
+
+	fmt.Fprintln(debugger.out, "🐛 Skibidi debugger. Type help for commands.")
+	lexer := NewLexer(code)
+	parser := NewParser(lexer)
+	program := parser.Parse()
+	interpreter.Execute(program)
+	fmt.Fprintln(debugger.out, "✅ Program finished")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// debugSession runs code under the terminal debugger, typing commands at
+// its prompt, and returns everything it printed.
+func debugSession(t *testing.T, code string, commands ...string) string {
+	t.Helper()
+
+	var typed strings.Builder
+	for _, command := range commands {
+		typed.WriteString(command + "\n")
+	}
+	input := filepath.Join(t.TempDir(), "commands")
+	if err := os.WriteFile(input, []byte(typed.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() {
+		os.Stdin = oldStdin
+	}()
+
+	return captureStdout(t, func() {
+		runDebug("double.skibidi", code)
+	})
+}
+
+const debugProgram = `sigma double(n) {
+    skibidi result rizz n * 2 ohio
+    alpha result ohio
+}
+skibidi x rizz 5 ohio
+gyatt beta double(x) ohio
+gyatt "done" ohio
+`
+
+func TestDebuggerSession(t *testing.T) {
+	got := debugSession(t, debugProgram, "b 2", "c", "p n + 1", "bt", "v", "n", "n", "c")
+	want := `🐛 Skibidi debugger. Type help for commands.
+⏸  double.skibidi:1
+>    1 | sigma double(n) {
+(debug) Breakpoint set at double.skibidi:2
+(debug) ⏸  double.skibidi:2
+>    2 |     skibidi result rizz n * 2 ohio
+(debug) 6
+(debug)   sigma double(n) at double.skibidi:2
+  <main> at double.skibidi:6
+(debug) [1] sigma double
+    n = 5
+[0] globals
+    x = 5
+(debug) ⏸  double.skibidi:3
+>    3 |     alpha result ohio
+(debug) 10
+⏸  double.skibidi:7
+>    7 | gyatt "done" ohio
+(debug) done
+✅ Program finished
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDebuggerCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		want     string // printed after the first prompt
+	}{
+		{"quit", []string{"q"}, "👋 Debugging stopped\n"},
+		{"end of input", nil, "\n👋 Debugging stopped\n"},
+		{"bad expression", []string{"p missing", "q"}, "Skibidi Error: Undefined variable: missing at line 1\n(debug) 👋 Debugging stopped\n"},
+		{"bad line", []string{"b 99", "q"}, "Invalid line number \"99\"\n(debug) 👋 Debugging stopped\n"},
+		{"breakpoints", []string{"b 6", "b 2", "breaks", "d 6", "breaks", "q"},
+			"Breakpoint set at double.skibidi:6\n(debug) Breakpoint set at double.skibidi:2\n" +
+				"(debug)   double.skibidi:2\n  double.skibidi:6\n(debug) Breakpoint cleared at double.skibidi:6\n" +
+				"(debug)   double.skibidi:2\n(debug) 👋 Debugging stopped\n"},
+		{"step into and out", []string{"n", "n", "s", "o", "q"},
+			"⏸  double.skibidi:5\n>    5 | skibidi x rizz 5 ohio\n" +
+				"(debug) ⏸  double.skibidi:6\n>    6 | gyatt beta double(x) ohio\n" +
+				"(debug) ⏸  double.skibidi:2\n>    2 |     skibidi result rizz n * 2 ohio\n" +
+				"(debug) 10\n⏸  double.skibidi:7\n>    7 | gyatt \"done\" ohio\n(debug) 👋 Debugging stopped\n"},
+		{"unknown", []string{"jump", "q"}, "Unknown command \"jump\". Type help for commands.\n(debug) 👋 Debugging stopped\n"},
+	}
+	const start = "(debug) "
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := debugSession(t, debugProgram, test.commands...)
+			idx := strings.Index(out, start)
+			if idx < 0 {
+				t.Fatalf("no prompt in:\n%s", out)
+			}
+			if got := out[idx+len(start):]; got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
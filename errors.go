@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrorValue is an error as Skibidi programs see it: what copium binds and
+// what yeet raises. Runtime errors become ErrorValues when they are caught.
+type ErrorValue struct {
+	Message string
+	Kind    string // e.g. "NameError"; plain yeets are "Error"
+	Line    int    // 0 when not known
+}
+
+func (e *ErrorValue) Error() string {
+	msg := e.Message
+	if e.Kind != "Error" {
+		msg = e.Kind + ": " + msg
+	}
+	if e.Line > 0 {
+		msg += fmt.Sprintf(" at line %d", e.Line)
+	}
+	return msg
+}
+
+// errorKinds classifies runtime error messages by how they start.
+var errorKinds = []struct {
+	prefix, kind string
+}{
+	{"Undefined ", "NameError"},
+	{"Division by zero", "ZeroDivisionError"},
+	{"Modulo by zero", "ZeroDivisionError"},
+	{"Index ", "IndexError"},
+	{"Key ", "KeyError"},
+	{"Permission denied", "PermissionError"},
+	{"json_parse", "JSONError"},
+	{"Cannot find module", "ImportError"},
+	{"Cannot read module", "ImportError"},
+	{"Import cycle", "ImportError"},
+	{"Cannot assign to undeclared", "NameError"},
+	{"Cannot redeclare", "NameError"},
+	{"Cannot ", "TypeError"}, // null in arithmetic, indexing a number
+}
+
+func errorKind(msg string) string {
+	for _, k := range errorKinds {
+		if strings.HasPrefix(msg, k.prefix) {
+			return k.kind
+		}
+	}
+	switch {
+	case strings.Contains(msg, " expects "):
+		return "TypeError"
+	case strings.Contains(msg, ") failed: "):
+		return "IOError"
+	}
+	return "RuntimeError"
+}
+
+// toErrorValue turns whatever a panic carried into an ErrorValue, or nil
+// if it isn't something a program may catch: exit(), quitting the
+// debugger, and faults in the interpreter itself.
+func (i *Interpreter) toErrorValue(r interface{}) *ErrorValue {
+	switch e := r.(type) {
+	case *ErrorValue:
+		return e
+	case *AssertionError:
+		return &ErrorValue{Message: e.Message, Kind: "AssertionError", Line: e.Line}
+	case *ExitError, debugQuit, runtime.Error:
+		return nil
+	case string:
+		line := errorLine(e)
+		message := e
+		if idx := strings.LastIndex(e, " at line "); idx >= 0 {
+			if _, err := strconv.Atoi(e[idx+len(" at line "):]); err == nil {
+				message = e[:idx]
+			}
+		}
+		if line == 0 {
+			line = i.line
+		}
+		return &ErrorValue{Message: message, Kind: errorKind(e), Line: line}
+	case error:
+		return &ErrorValue{Message: e.Error(), Kind: "RuntimeError", Line: i.line}
+	}
+	return &ErrorValue{Message: fmt.Sprint(r), Kind: "RuntimeError", Line: i.line}
+}
+
+// throwValue makes the error a yeet raises: error values are raised as
+// they are, anything else becomes the message of a plain Error.
+func (i *Interpreter) throwValue(value interface{}, line int) *ErrorValue {
+	if e, ok := value.(*ErrorValue); ok {
+		return e
+	}
+	return &ErrorValue{Message: i.toString(value), Kind: "Error", Line: line}
+}
+
+// runTry runs a vibecheck statement. Scopes and sigma calls the error
+// escaped from are unwound before copium runs, and touchgrass runs however
+// the statement ends.
+func (i *Interpreter) runTry(s *TryStmt) {
+	depth := len(i.callStack)
+	if s.FinallyBlock != nil {
+		defer func() {
+			r := recover()
+			if _, quit := r.(debugQuit); quit {
+				panic(r)
+			}
+			if r != nil {
+				i.unwindTo(depth)
+			}
+			// An alpha in the body still returns after touchgrass, unless
+			// touchgrass returns something else
+			returning, value := i.returning, i.returnValue
+			i.returning, i.returnValue = false, nil
+			i.runBlock(s.FinallyBlock)
+			if i.returning {
+				return
+			}
+			i.returning, i.returnValue = returning, value
+			if r != nil {
+				panic(r)
+			}
+		}()
+	}
+	if s.CatchBlock == nil {
+		i.runBlock(s.Body)
+		return
+	}
+	if caught := i.tryBlock(s.Body, depth); caught != nil {
+		i.pushScope()
+		i.currentFrame().define(s.CatchName, caught)
+		i.runStatements(s.CatchBlock)
+		i.popScope()
+	}
+}
+
+// tryBlock runs body and returns the error it raised, if any.
+func (i *Interpreter) tryBlock(body []ASTNode, depth int) (caught *ErrorValue) {
+	defer func() {
+		if r := recover(); r != nil {
+			if caught = i.toErrorValue(r); caught == nil {
+				panic(r)
+			}
+			i.unwindTo(depth)
+		}
+	}()
+	i.runBlock(body)
+	return nil
+}
+
+// unwindTo pops frames until depth are left, telling observers about each
+// sigma call that is abandoned.
+func (i *Interpreter) unwindTo(depth int) {
+	for len(i.callStack) > depth {
+		frame := i.currentFrame()
+		if frame.function != nil {
+			callDepth := i.callDepth()
+			for _, o := range i.observers {
+				o.Exit(frame.function, nil, callDepth)
+			}
+		}
+		i.callStack = i.callStack[:len(i.callStack)-1]
+	}
+}
+
+func init() {
+	registerBuiltin("error", 1, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		kind := "Error"
+		if len(args) == 2 {
+			kind = stringArg(name, args, 1)
+		}
+		return &ErrorValue{Message: i.toString(args[0]), Kind: kind, Line: line}
+	})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Permissions says which directories and environment variables a program
+// may touch. Everything is denied until --allow-read or --allow-write
+// grants a directory, which covers everything below it too, or --allow-env
+// grants variables.
+type Permissions struct {
+	read   []string
+	write  []string
+	env    []string // environment variables env and set_env may use
+	allEnv bool
+}
+
+// AllowRead lets programs read files under dir.
+func (p *Permissions) AllowRead(dir string) error {
+	resolved, err := resolvePath(dir)
+	if err != nil {
+		return err
+	}
+	p.read = append(p.read, resolved)
+	return nil
+}
+
+// AllowWrite lets programs create, change and remove files under dir.
+func (p *Permissions) AllowWrite(dir string) error {
+	resolved, err := resolvePath(dir)
+	if err != nil {
+		return err
+	}
+	p.write = append(p.write, resolved)
+	return nil
+}
+
+// resolvePath makes path absolute and follows symlinks as far as the path
+// exists, so a link can't be used to step outside an allowed directory.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rest := ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return filepath.Join(abs, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(abs), rest)
+		abs = parent
+	}
+}
+
+func within(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRead returns the path a built-in may read, or panics if the program
+// wasn't given access to it.
+func (i *Interpreter) checkRead(name, path string, line int) string {
+	resolved, err := resolvePath(path)
+	if err != nil || !within(resolved, i.permissions.read) {
+		panic(fmt.Sprintf("Permission denied: %s(%q) needs --allow-read at line %d", name, path, line))
+	}
+	return resolved
+}
+
+func (i *Interpreter) checkWrite(name, path string, line int) string {
+	resolved, err := resolvePath(path)
+	if err != nil || !within(resolved, i.permissions.write) {
+		panic(fmt.Sprintf("Permission denied: %s(%q) needs --allow-write at line %d", name, path, line))
+	}
+	return resolved
+}
+
+// fileError turns an I/O error into a runtime error that names the file
+// the way the program did, not by its resolved path.
+func fileError(name, path string, err error, line int) {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	panic(fmt.Sprintf("%s(%q) failed: %v at line %d", name, path, err, line))
+}
+
+func readFile(i *Interpreter, name string, args []interface{}, line int) string {
+	path := stringArg(name, args, 0)
+	content, err := os.ReadFile(i.checkRead(name, path, line))
+	if err != nil {
+		fileError(name, path, err, line)
+	}
+	return string(content)
+}
+
+func init() {
+	registerBuiltin("read_file", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return readFile(i, name, args, line)
+	})
+	registerBuiltin("read_lines", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		content := readFile(i, name, args, line)
+		items := []interface{}{}
+		if content != "" {
+			for _, text := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+				items = append(items, strings.TrimSuffix(text, "\r"))
+			}
+		}
+		return &List{Items: items}
+	})
+	registerBuiltin("write_file", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		path := stringArg(name, args, 0)
+		if err := os.WriteFile(i.checkWrite(name, path, line), []byte(i.toString(args[1])), 0644); err != nil {
+			fileError(name, path, err, line)
+		}
+		return nil
+	})
+	registerBuiltin("append_file", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		path := stringArg(name, args, 0)
+		file, err := os.OpenFile(i.checkWrite(name, path, line), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			fileError(name, path, err, line)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(i.toString(args[1])); err != nil {
+			fileError(name, path, err, line)
+		}
+		return nil
+	})
+	registerBuiltin("exists", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		_, err := os.Stat(i.checkRead(name, stringArg(name, args, 0), line))
+		return err == nil
+	})
+	registerBuiltin("list_dir", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		path := stringArg(name, args, 0)
+		entries, err := os.ReadDir(i.checkRead(name, path, line))
+		if err != nil {
+			fileError(name, path, err, line)
+		}
+		// ReadDir sorts by name already
+		items := make([]interface{}, len(entries))
+		for idx, entry := range entries {
+			items[idx] = entry.Name()
+		}
+		return &List{Items: items}
+	})
+	// remove deletes a file or an empty directory
+	registerBuiltin("remove", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		path := stringArg(name, args, 0)
+		if err := os.Remove(i.checkWrite(name, path, line)); err != nil {
+			fileError(name, path, err, line)
+		}
+		return nil
+	})
+}
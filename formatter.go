@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const formatIndent = "    "
+
+// Formatter reprints an AST as canonical Skibidi source.
+type Formatter struct {
+	lines []string
+	depth int
+}
+
+// formatSource parses code with comments kept and returns it reformatted.
+func formatSource(code string) (formatted string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	lexer := NewLexer(code)
+	lexer.keepComments = true
+	parser := NewParser(lexer)
+	program := parser.Parse()
+	return Format(program), nil
+}
+
+// Format returns the canonical source for program: four-space indentation,
+// one statement per line, single spaces around operators and nocap on the
+// line after the closing brace of its cap.
+func Format(program *Program) string {
+	f := &Formatter{}
+	f.formatStatements(program.Statements)
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// line adds text at the current depth. Text broken over several lines by
+// comments inside an expression carries on one level deeper.
+func (f *Formatter) line(text string) {
+	parts := strings.Split(text, "\n")
+	for idx, part := range parts {
+		depth := f.depth
+		if idx > 0 {
+			depth++
+			part = strings.TrimLeft(part, " ")
+		}
+		if idx < len(parts)-1 {
+			part = strings.TrimRight(part, " ")
+		}
+		f.lines = append(f.lines, strings.Repeat(formatIndent, depth)+part)
+	}
+}
+
+func (f *Formatter) formatStatements(statements []ASTNode) {
+	start := len(f.lines)
+	for idx, stmt := range statements {
+		switch s := stmt.(type) {
+		case *BlankLine:
+			// Collapse runs of blank lines and drop them at block edges
+			last := len(f.lines) - 1
+			if len(f.lines) == start || f.lines[last] == "" || idx == len(statements)-1 {
+				continue
+			}
+			f.lines = append(f.lines, "")
+		case *Comment:
+			if s.Closing {
+				continue // formatBlock puts it after the brace
+			}
+			if s.Trailing && len(f.lines) > 0 {
+				f.lines[len(f.lines)-1] += " " + s.Text
+				continue
+			}
+			f.line(s.Text)
+		default:
+			f.formatStatement(stmt)
+		}
+	}
+	// A blank line kept before trailing comments can still end the block
+	if len(f.lines) > start && f.lines[len(f.lines)-1] == "" {
+		f.lines = f.lines[:len(f.lines)-1]
+	}
+}
+
+func (f *Formatter) formatBlock(header string, body []ASTNode) {
+	f.line(header + " {")
+	f.depth++
+	f.formatStatements(body)
+	f.depth--
+	closing := "}"
+	for _, stmt := range body {
+		if c, ok := stmt.(*Comment); ok && c.Closing {
+			closing += " " + c.Text
+		}
+	}
+	f.line(closing)
+}
+
+func (f *Formatter) formatStatement(stmt ASTNode) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		f.line(formatSimpleStatement(s) + " ohio")
+	case *Assignment:
+		f.line(formatSimpleStatement(s) + " ohio")
+	case *PrintStmt:
+		f.line("gyatt " + formatExpression(s.Value) + " ohio")
+	case *IfStmt:
+		f.formatBlock("cap ("+formatExpression(s.Condition)+")", s.ThenBlock)
+		if s.ElseBlock != nil {
+			f.formatBlock("nocap", s.ElseBlock)
+		}
+	case *WhileStmt:
+		f.formatBlock("bussin ("+formatExpression(s.Condition)+")", s.Body)
+	case *ForStmt:
+		header := fmt.Sprintf("gyatfor (%s; %s; %s)",
+			formatSimpleStatement(s.Init), formatExpression(s.Condition), formatSimpleStatement(s.Post))
+		f.formatBlock(header, s.Body)
+	case *SigmaFunc:
+		f.formatBlock(fmt.Sprintf("sigma %s(%s)", s.Name, strings.Join(s.Params, ", ")), s.Body)
+	case *BetaCall:
+		f.line(formatExpression(s) + " ohio")
+	case *AlphaReturn:
+		if s.Value == nil {
+			f.line("alpha ohio")
+		} else {
+			f.line("alpha " + formatExpression(s.Value) + " ohio")
+		}
+	case *TryStmt:
+		f.formatBlock("vibecheck", s.Body)
+		if s.CatchBlock != nil {
+			f.formatBlock("copium ("+s.CatchName+")", s.CatchBlock)
+		}
+		if s.FinallyBlock != nil {
+			f.formatBlock("touchgrass", s.FinallyBlock)
+		}
+	case *ThrowStmt:
+		f.line("yeet " + formatExpression(s.Value) + " ohio")
+	case *ImportStmt:
+		text := "import " + quoteString(s.Path)
+		if s.Name != strings.TrimSuffix(filepath.Base(s.Path), filepath.Ext(s.Path)) {
+			text += " as " + s.Name
+		}
+		f.line(text + " ohio")
+	default:
+		panic(fmt.Sprintf("Cannot format statement: %T", stmt))
+	}
+}
+
+// formatSimpleStatement formats a declaration or assignment without its
+// ohio, as used in gyatfor headers.
+func formatSimpleStatement(stmt ASTNode) string {
+	switch s := stmt.(type) {
+	case nil:
+		return ""
+	case *VarDecl:
+		keyword := "skibidi"
+		if s.Constant {
+			keyword = "deadass"
+		}
+		return fmt.Sprintf("%s %s rizz %s", keyword, s.Name, formatExpression(s.Value))
+	case *Assignment:
+		return fmt.Sprintf("%s rizz %s", s.Name, formatExpression(s.Value))
+	}
+	panic(fmt.Sprintf("Cannot format statement: %T", stmt))
+}
+
+// operatorPrecedence mirrors the parser's grammar, loosest binding first.
+func operatorPrecedence(op string) int {
+	switch op {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "==", "<", ">", "<=", ">=":
+		return 3
+	case "+", "-":
+		return 4
+	case "*", "/", "%":
+		return 5
+	}
+	return 6
+}
+
+// expressionPrecedence returns how tightly node binds when printed.
+func expressionPrecedence(node ASTNode) int {
+	if c, ok := node.(*CommentedExpr); ok {
+		return expressionPrecedence(c.Expr)
+	}
+	if b, ok := node.(*BinaryOp); ok {
+		if isNegation(b) {
+			return 6
+		}
+		return operatorPrecedence(b.Operator)
+	}
+	return 7
+}
+
+// isNegation reports whether b is how the parser represents unary minus.
+func isNegation(b *BinaryOp) bool {
+	zero, ok := b.Left.(*NumberLiteral)
+	return ok && zero.Value == 0 && b.Operator == "-"
+}
+
+func formatOperand(node ASTNode, minPrecedence int) string {
+	text := formatExpression(node)
+	if expressionPrecedence(node) < minPrecedence {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func formatExpression(node ASTNode) string {
+	switch n := node.(type) {
+	case *NumberLiteral:
+		return strconv.FormatFloat(n.Value, 'f', -1, 64)
+	case *StringLiteral:
+		return quoteString(n.Value)
+	case *BoolLiteral:
+		return strconv.FormatBool(n.Value)
+	case *NullLiteral:
+		return "null"
+	case *Identifier:
+		if n.Module != "" {
+			return n.Module + "." + n.Name
+		}
+		return n.Name
+	case *InputExpr:
+		return "input"
+	case *BinaryOp:
+		if isNegation(n) {
+			return "-" + formatOperand(n.Right, 6)
+		}
+		prec := operatorPrecedence(n.Operator)
+		// Operators are left-associative, so only the right side needs
+		// parentheses at equal precedence
+		return formatOperand(n.Left, prec) + " " + n.Operator + " " + formatOperand(n.Right, prec+1)
+	case *BetaCall:
+		args := make([]string, len(n.Args))
+		for idx, arg := range n.Args {
+			args[idx] = formatExpression(arg)
+		}
+		name := n.Name
+		if n.Module != "" {
+			name = n.Module + "." + name
+		}
+		call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+		if n.Beta {
+			return "beta " + call
+		}
+		return call
+	case *ListLiteral:
+		elements := make([]string, len(n.Elements))
+		for idx, element := range n.Elements {
+			elements[idx] = formatExpression(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *IndexExpr:
+		// Indexing binds tighter than unary minus, so only literals,
+		// names, calls and other indexes go bare
+		return formatOperand(n.Target, 7) + "[" + formatExpression(n.Index) + "]"
+	case *CommentedExpr:
+		// A comment ends its line, so the code after it starts a new one
+		var text strings.Builder
+		for _, c := range n.Before {
+			if !c.Trailing {
+				text.WriteString("\n")
+			}
+			text.WriteString(c.Text + "\n")
+		}
+		text.WriteString(formatExpression(n.Expr))
+		for _, c := range n.After {
+			if c.Trailing {
+				text.WriteString(" " + c.Text)
+			} else {
+				text.WriteString("\n" + c.Text)
+			}
+		}
+		if len(n.After) > 0 {
+			text.WriteString("\n")
+		}
+		return text.String()
+	}
+	panic(fmt.Sprintf("Cannot format expression: %T", node))
+}
+
+// quoteString is the inverse of Lexer.readString.
+func quoteString(s string) string {
+	var result strings.Builder
+	result.WriteByte('"')
+	for idx := 0; idx < len(s); idx++ {
+		switch s[idx] {
+		case '\n':
+			result.WriteString(`\n`)
+		case '\t':
+			result.WriteString(`\t`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '"':
+			result.WriteString(`\"`)
+		default:
+			result.WriteByte(s[idx])
+		}
+	}
+	result.WriteByte('"')
+	return result.String()
+}
+
+// runFmt formats each file, printing the result or, with write set,
+// rewriting files whose formatting changed. It returns false on any error.
+func runFmt(files []string, write bool) bool {
+	ok := true
+	for _, filename := range files {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Printf("❌ Error reading file '%s': %v\n", filename, err)
+			ok = false
+			continue
+		}
+		formatted, err := formatSource(string(content))
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", filename, err)
+			ok = false
+			continue
+		}
+		if !write {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted == string(content) {
+			continue
+		}
+		if err := os.WriteFile(filename, []byte(formatted), 0644); err != nil {
+			fmt.Printf("❌ Error writing file '%s': %v\n", filename, err)
+			ok = false
+			continue
+		}
+		fmt.Printf("✨ Formatted %s\n", filename)
+	}
+	return ok
+}
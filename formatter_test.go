@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFormatKeepsComments checks that comments stay next to the code they
+// were written after, and that formatting the result changes nothing.
+func TestFormatKeepsComments(t *testing.T) {
+	tests := []struct{ code, want string }{
+		{
+			"cap (x > 1) {\ngyatt 1 ohio\n} bruh then\nnocap {\ngyatt 2 ohio\n}\n",
+			"cap (x > 1) {\n    gyatt 1 ohio\n} bruh then\nnocap {\n    gyatt 2 ohio\n}\n",
+		},
+		{
+			"vibecheck {\ngyatt 1 ohio\n} bruh try\ncopium (e) {\ngyatt e ohio\n} bruh catch\n",
+			"vibecheck {\n    gyatt 1 ohio\n} bruh try\ncopium (e) {\n    gyatt e ohio\n} bruh catch\n",
+		},
+		{
+			"skibidi y rizz 1 + bruh mid\n2 ohio\n",
+			"skibidi y rizz 1 + bruh mid\n    2 ohio\n",
+		},
+		{
+			"skibidi y rizz add(1,\n    bruh own line\n    2) ohio\n",
+			"skibidi y rizz add(1,\n    bruh own line\n    2) ohio\n",
+		},
+		{
+			"skibidi y rizz x bruh before the operator\n* 2 ohio bruh end\n",
+			"skibidi y rizz x bruh before the operator\n    * 2 ohio bruh end\n",
+		},
+	}
+	for _, test := range tests {
+		got, err := formatSource(test.code)
+		if err != nil {
+			t.Fatalf("%q: %v", test.code, err)
+		}
+		if got != test.want {
+			t.Errorf("formatting %q gave\n%s\nwant\n%s", test.code, got, test.want)
+			continue
+		}
+		if again, _ := formatSource(got); again != got {
+			t.Errorf("formatting %q again gave\n%s", got, again)
+		}
+	}
+}
+
+// TestFormatGolden formats every test/*.skibidi program and checks that the
+// result is already formatted and still prints the program's .out file.
+func TestFormatGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("test", "*.skibidi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".skibidi")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			code, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted, err := formatSource(string(code))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again, err := formatSource(formatted); err != nil || again != formatted {
+				t.Errorf("formatting twice changed the code (%v):\n%s", err, again)
+			}
+			want, err := os.ReadFile(base + ".out")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := captureRun(t, file, formatted, base+".in"); got != string(want) {
+				t.Errorf("formatted program printed\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestFormatCanonical checks the layout the formatter settles on.
+func TestFormatCanonical(t *testing.T) {
+	code := "skibidi   x rizz (1+2)*3 ohio\ncap(x>5){gyatt \"big\" ohio}nocap{\n\n\ngyatt -(x) ohio\n}\nsigma add(a,b){alpha a+(b-1) ohio}\n"
+	want := `skibidi x rizz (1 + 2) * 3 ohio
+cap (x > 5) {
+    gyatt "big" ohio
+}
+nocap {
+    gyatt -x ohio
+}
+sigma add(a, b) {
+    alpha a + (b - 1) ohio
+}
+`
+	got, err := formatSource(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the .out golden files in test/")
+
+// captureStdout calls fn and returns everything it wrote to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %v", err)
+	}
+
+	oldStdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.Bytes()
+	}()
+
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+// captureRun runs file's code with stdin read from stdinPath (or empty when
+// there is none) and returns everything written to stdout.
+func captureRun(t *testing.T, file string, code string, stdinPath string) string {
+	t.Helper()
+
+	stdin, err := os.Open(os.DevNull)
+	if _, statErr := os.Stat(stdinPath); statErr == nil {
+		stdin, err = os.Open(stdinPath)
+	}
+	if err != nil {
+		t.Fatalf("opening stdin: %v", err)
+	}
+	defer stdin.Close()
+
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() {
+		os.Stdin = oldStdin
+	}()
+
+	return captureStdout(t, func() {
+		interpreter := NewInterpreter()
+		interpreter.SetFile(file)
+		// Programs may read the test data next to them, but not write
+		if err := interpreter.permissions.AllowRead(filepath.Dir(file)); err != nil {
+			t.Error(err)
+			return
+		}
+		runSkibidiInterpreter(code, interpreter)
+	})
+}
+
+// TestGolden runs every test/*.skibidi program and compares its output with
+// the matching .out file. A .in file next to the program is fed to stdin.
+// Run `go test *.go -update` to regenerate the .out files.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("test", "*.skibidi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test/*.skibidi programs found")
+	}
+
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".skibidi")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			code, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := captureRun(t, file, string(code), base+".in")
+
+			goldenPath := base + ".out"
+			if *update {
+				if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update to create it): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("output mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", file, got, want)
+			}
+		})
+	}
+}
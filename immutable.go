@@ -0,0 +1,59 @@
+package main
+
+import "fmt"
+
+// freeze returns a frozen copy of a list or map, with the lists and maps
+// inside it frozen copies too, so built-ins that change collections in
+// place refuse to. Other names for the original can still change it. A
+// value that is already frozen, or can't change anyway, is returned as is.
+func freeze(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *List:
+		if v.Frozen {
+			return v
+		}
+		items := make([]interface{}, len(v.Items))
+		for idx, item := range v.Items {
+			items[idx] = freeze(item)
+		}
+		return &List{Items: items, Frozen: true}
+	case *Map:
+		if v.Frozen {
+			return v
+		}
+		m := NewMap()
+		for _, key := range v.Keys {
+			m.Set(key, freeze(v.Values[key]))
+		}
+		m.Frozen = true
+		return m
+	}
+	return value
+}
+
+func isFrozen(value interface{}) bool {
+	switch v := value.(type) {
+	case *List:
+		return v.Frozen
+	case *Map:
+		return v.Frozen
+	}
+	// Numbers, strings and the rest can't be changed anyway
+	return true
+}
+
+// checkMutable panics if a built-in is about to change a frozen collection.
+func checkMutable(name string, value interface{}, line int) {
+	if isFrozen(value) {
+		panic(fmt.Sprintf("Cannot %s a frozen %s at line %d", name, typeName(value), line))
+	}
+}
+
+func init() {
+	registerBuiltin("freeze", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return freeze(args[0])
+	})
+	registerBuiltin("is_frozen", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return isFrozen(args[0])
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// jsonError reports a json_parse failure at a byte offset into the input.
+func jsonError(msg string, offset int64, line int) {
+	panic(fmt.Sprintf("json_parse: %s at byte offset %d at line %d", msg, offset, line))
+}
+
+// parseJSON turns JSON text into Skibidi values: objects become maps,
+// arrays become lists and null becomes null.
+func parseJSON(text string, line int) interface{} {
+	dec := json.NewDecoder(strings.NewReader(text))
+	value := parseJSONValue(dec, int64(len(text)), line)
+	if _, err := dec.Token(); err != io.EOF {
+		offset := dec.InputOffset()
+		for offset < int64(len(text)) && strings.ContainsRune(" \t\r\n", rune(text[offset])) {
+			offset++
+		}
+		jsonError("unexpected data after the value", offset, line)
+	}
+	return value
+}
+
+func parseJSONValue(dec *json.Decoder, end int64, line int) interface{} {
+	token := readJSONToken(dec, end, line)
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			list := &List{Items: []interface{}{}}
+			for dec.More() {
+				list.Items = append(list.Items, parseJSONValue(dec, end, line))
+			}
+			readJSONToken(dec, end, line)
+			return list
+		case '{':
+			m := NewMap()
+			for dec.More() {
+				key := readJSONToken(dec, end, line).(string)
+				m.Set(key, parseJSONValue(dec, end, line))
+			}
+			readJSONToken(dec, end, line)
+			return m
+		}
+		jsonError(fmt.Sprintf("unexpected %q", rune(t)), dec.InputOffset()-1, line)
+	}
+	return token
+}
+
+func readJSONToken(dec *json.Decoder, end int64, line int) json.Token {
+	token, err := dec.Token()
+	if err == nil {
+		return token
+	}
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		jsonError(syntaxErr.Error(), syntaxErr.Offset, line)
+	case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+		jsonError("unexpected end of input", end, line)
+	}
+	jsonError(err.Error(), dec.InputOffset(), line)
+	return nil
+}
+
+// stringifyJSON writes value as JSON. With indent > 0 each item goes on a
+// line of its own, indented by that many spaces per level.
+func (i *Interpreter) stringifyJSON(b *strings.Builder, value interface{}, indent int, depth int, line int) {
+	newline := func(depth int) {
+		if indent > 0 {
+			b.WriteString("\n" + strings.Repeat(" ", indent*depth))
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(i.toString(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			panic(fmt.Sprintf("json_stringify cannot represent %v at line %d", v, line))
+		}
+		b.WriteString(i.toString(v))
+	case string:
+		b.WriteString(jsonString(v))
+	case *List:
+		if len(v.Items) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[")
+		for idx, item := range v.Items {
+			if idx > 0 {
+				b.WriteString(",")
+			}
+			newline(depth + 1)
+			i.stringifyJSON(b, item, indent, depth+1, line)
+		}
+		newline(depth)
+		b.WriteString("]")
+	case *Map:
+		if len(v.Keys) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteString("{")
+		for idx, key := range v.Keys {
+			if idx > 0 {
+				b.WriteString(",")
+			}
+			newline(depth + 1)
+			b.WriteString(jsonString(key))
+			b.WriteString(":")
+			if indent > 0 {
+				b.WriteString(" ")
+			}
+			i.stringifyJSON(b, v.Values[key], indent, depth+1, line)
+		}
+		newline(depth)
+		b.WriteString("}")
+	default:
+		panic(fmt.Sprintf("json_stringify cannot represent a %s at line %d", typeName(value), line))
+	}
+}
+
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func init() {
+	registerBuiltin("json_parse", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return parseJSON(stringArg(name, args, 0), line)
+	})
+	registerBuiltin("json_stringify", 1, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		indent := 0
+		if len(args) == 2 {
+			indent = intArg(name, args, 1)
+		}
+		var b strings.Builder
+		i.stringifyJSON(&b, args[0], indent, 0, line)
+		return b.String()
+	})
+	registerBuiltin("keys", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		m, ok := args[0].(*Map)
+		if !ok {
+			argError(name, args, 0, "a map")
+		}
+		items := make([]interface{}, len(m.Keys))
+		for idx, key := range m.Keys {
+			items[idx] = key
+		}
+		return &List{Items: items}
+	})
+}
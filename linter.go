@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LintWarning is a single finding reported by skibidi lint.
+type LintWarning struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", w.File, w.Line, w.Code, w.Message)
+}
+
+// lintVar is one skibidi declaration (or function parameter).
+type lintVar struct {
+	name string
+	line int
+	read bool
+}
+
+type lintScope struct {
+	vars   map[string]*lintVar
+	parent *lintScope
+}
+
+func newLintScope(parent *lintScope) *lintScope {
+	return &lintScope{vars: make(map[string]*lintVar), parent: parent}
+}
+
+func (s *lintScope) lookup(name string) *lintVar {
+	for scope := s; scope != nil; scope = scope.parent {
+		if v, ok := scope.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// Linter walks a parsed program looking for likely mistakes without
+// running it.
+type Linter struct {
+	file      string
+	warnings  []LintWarning
+	functions map[string]*SigmaFunc
+	called    map[string]bool
+	decls     []*lintVar
+}
+
+func NewLinter(file string) *Linter {
+	return &Linter{
+		file:      file,
+		functions: make(map[string]*SigmaFunc),
+		called:    make(map[string]bool),
+	}
+}
+
+func (l *Linter) warn(line int, code string, format string, args ...interface{}) {
+	l.warnings = append(l.warnings, LintWarning{File: l.file, Line: line, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Lint returns the warnings for program, ordered by line.
+func (l *Linter) Lint(program *Program) []LintWarning {
+	l.collectFunctions(program.Statements)
+
+	globals := newLintScope(nil)
+	l.lintStatements(program.Statements, globals)
+
+	// Function bodies run after the top level has declared its globals
+	for _, fn := range l.sortedFunctions() {
+		scope := newLintScope(globals)
+		for _, param := range fn.Params {
+			scope.vars[param] = &lintVar{name: param, line: fn.Line, read: true}
+		}
+		l.lintStatements(fn.Body, scope)
+	}
+
+	for _, v := range l.decls {
+		if !v.read {
+			l.warn(v.line, "unused-variable", "variable %s is declared but never read", v.name)
+		}
+	}
+	for _, fn := range l.sortedFunctions() {
+		if !l.called[fn.Name] && !strings.HasPrefix(fn.Name, "test_") {
+			l.warn(fn.Line, "unused-function", "function %s is never called", fn.Name)
+		}
+	}
+
+	sort.SliceStable(l.warnings, func(a, b int) bool {
+		return l.warnings[a].Line < l.warnings[b].Line
+	})
+	return l.warnings
+}
+
+func (l *Linter) sortedFunctions() []*SigmaFunc {
+	fns := make([]*SigmaFunc, 0, len(l.functions))
+	for _, fn := range l.functions {
+		fns = append(fns, fn)
+	}
+	sort.Slice(fns, func(a, b int) bool { return fns[a].Line < fns[b].Line })
+	return fns
+}
+
+// collectFunctions finds every sigma in the program, including ones
+// declared inside blocks, so calls can be checked before the definition.
+func (l *Linter) collectFunctions(statements []ASTNode) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *SigmaFunc:
+			l.functions[s.Name] = s
+			l.collectFunctions(s.Body)
+		case *IfStmt:
+			l.collectFunctions(s.ThenBlock)
+			l.collectFunctions(s.ElseBlock)
+		case *WhileStmt:
+			l.collectFunctions(s.Body)
+		case *ForStmt:
+			l.collectFunctions(s.Body)
+		case *TryStmt:
+			l.collectFunctions(s.Body)
+			l.collectFunctions(s.CatchBlock)
+			l.collectFunctions(s.FinallyBlock)
+		}
+	}
+}
+
+func (l *Linter) declare(scope *lintScope, name string, line int) {
+	v := &lintVar{name: name, line: line}
+	scope.vars[name] = v
+	l.decls = append(l.decls, v)
+}
+
+func (l *Linter) lintStatements(statements []ASTNode, scope *lintScope) {
+	returned := false
+	for _, stmt := range statements {
+		switch stmt.(type) {
+		case *Comment, *BlankLine:
+			continue
+		}
+		if returned {
+			l.warn(nodeLine(stmt), "unreachable-code", "unreachable code after alpha")
+			return
+		}
+		l.lintStatement(stmt, scope)
+		if _, ok := stmt.(*AlphaReturn); ok {
+			returned = true
+		}
+	}
+}
+
+func (l *Linter) lintStatement(stmt ASTNode, scope *lintScope) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		l.lintExpression(s.Value, scope)
+		l.declare(scope, s.Name, s.Line)
+	case *Assignment:
+		l.lintExpression(s.Value, scope)
+		if scope.lookup(s.Name) == nil {
+			l.warn(s.Line, "undeclared-assignment", "assignment to undeclared variable %s (declare it with skibidi)", s.Name)
+		}
+	case *PrintStmt:
+		l.lintExpression(s.Value, scope)
+	case *IfStmt:
+		l.lintExpression(s.Condition, scope)
+		l.lintStatements(s.ThenBlock, newLintScope(scope))
+		l.lintStatements(s.ElseBlock, newLintScope(scope))
+	case *WhileStmt:
+		l.lintExpression(s.Condition, scope)
+		if b, ok := s.Condition.(*BoolLiteral); ok && b.Value && !containsReturn(s.Body) {
+			l.warn(s.Line, "infinite-loop", "bussin (true) loop has no alpha to exit it")
+		}
+		l.lintStatements(s.Body, newLintScope(scope))
+	case *ForStmt:
+		loopScope := newLintScope(scope)
+		if s.Init != nil {
+			l.lintStatement(s.Init, loopScope)
+		}
+		l.lintExpression(s.Condition, loopScope)
+		l.lintStatements(s.Body, newLintScope(loopScope))
+		if s.Post != nil {
+			l.lintStatement(s.Post, loopScope)
+		}
+	case *BetaCall:
+		l.lintExpression(s, scope)
+	case *AlphaReturn:
+		l.lintExpression(s.Value, scope)
+	case *ThrowStmt:
+		l.lintExpression(s.Value, scope)
+	case *TryStmt:
+		l.lintStatements(s.Body, newLintScope(scope))
+		if s.CatchBlock != nil {
+			catchScope := newLintScope(scope)
+			l.declare(catchScope, s.CatchName, s.Line)
+			// Nobody has to look at the error they caught
+			catchScope.vars[s.CatchName].read = true
+			l.lintStatements(s.CatchBlock, catchScope)
+		}
+		l.lintStatements(s.FinallyBlock, newLintScope(scope))
+	}
+}
+
+func (l *Linter) lintExpression(node ASTNode, scope *lintScope) {
+	switch n := node.(type) {
+	case *Identifier:
+		if n.Module != "" {
+			return
+		}
+		if v := scope.lookup(n.Name); v != nil {
+			v.read = true
+		} else if _, ok := l.functions[n.Name]; ok {
+			// Passing a function by name counts as using it
+			l.called[n.Name] = true
+		}
+	case *BinaryOp:
+		l.lintExpression(n.Left, scope)
+		l.lintExpression(n.Right, scope)
+	case *ListLiteral:
+		for _, element := range n.Elements {
+			l.lintExpression(element, scope)
+		}
+	case *IndexExpr:
+		l.lintExpression(n.Target, scope)
+		l.lintExpression(n.Index, scope)
+	case *BetaCall:
+		for _, arg := range n.Args {
+			l.lintExpression(arg, scope)
+		}
+		if n.Module != "" {
+			return
+		}
+		if v := scope.lookup(n.Name); v != nil {
+			v.read = true
+			return
+		}
+		l.called[n.Name] = true
+		if fn, ok := l.functions[n.Name]; ok {
+			if len(fn.Params) != len(n.Args) {
+				l.warn(n.Line, "arg-count", "function %s expects %d args, got %d", n.Name, len(fn.Params), len(n.Args))
+			}
+		} else if bounds, ok := builtinArgs[n.Name]; ok {
+			if len(n.Args) < bounds[0] || (bounds[1] >= 0 && len(n.Args) > bounds[1]) {
+				l.warn(n.Line, "arg-count", "%s expects %s, got %d", n.Name, describeArgCount(bounds), len(n.Args))
+			}
+		}
+	}
+}
+
+func describeArgCount(bounds [2]int) string {
+	switch {
+	case bounds[1] < 0 && bounds[0] == 1:
+		return "at least 1 argument"
+	case bounds[1] < 0:
+		return fmt.Sprintf("at least %d arguments", bounds[0])
+	case bounds[0] == 1 && bounds[1] == 1:
+		return "1 argument"
+	case bounds[0] == bounds[1]:
+		return fmt.Sprintf("%d arguments", bounds[0])
+	}
+	return fmt.Sprintf("%d or %d arguments", bounds[0], bounds[1])
+}
+
+// containsReturn reports whether an alpha appears anywhere in statements.
+func containsReturn(statements []ASTNode) bool {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *AlphaReturn:
+			return true
+		case *IfStmt:
+			if containsReturn(s.ThenBlock) || containsReturn(s.ElseBlock) {
+				return true
+			}
+		case *WhileStmt:
+			if containsReturn(s.Body) {
+				return true
+			}
+		case *ForStmt:
+			if containsReturn(s.Body) {
+				return true
+			}
+		case *TryStmt:
+			if containsReturn(s.Body) || containsReturn(s.CatchBlock) || containsReturn(s.FinallyBlock) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lintProgram lints a parsed program and adds the first name the resolver
+// rejects, unless the linter already reported it as an undeclared
+// assignment on that line, keeping the warnings in line order.
+func lintProgram(filename string, program *Program) []LintWarning {
+	warnings := NewLinter(filename).Lint(program)
+	if err := resolveProgram(program); err != nil {
+		line := errorLine(err.Error())
+		for _, w := range warnings {
+			if w.Line == line && w.Code == "undeclared-assignment" {
+				return warnings
+			}
+		}
+		warnings = append(warnings, LintWarning{File: filename, Line: line, Code: "name-error", Message: err.Error()})
+		sort.SliceStable(warnings, func(a, b int) bool {
+			return warnings[a].Line < warnings[b].Line
+		})
+	}
+	return warnings
+}
+
+// runLint lints each file and prints the warnings, as JSON when asJSON is
+// set. It returns false if there were warnings or errors.
+func runLint(files []string, asJSON bool) bool {
+	ok := true
+	warnings := []LintWarning{}
+	for _, filename := range files {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading file '%s': %v\n", filename, err)
+			ok = false
+			continue
+		}
+		program, err := parseProgram(string(content))
+		if err != nil {
+			warnings = append(warnings, LintWarning{File: filename, Line: errorLine(err.Error()), Code: "syntax-error", Message: err.Error()})
+			continue
+		}
+		warnings = append(warnings, lintProgram(filename, program)...)
+	}
+
+	if asJSON {
+		data, _ := json.MarshalIndent(warnings, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, w := range warnings {
+			fmt.Println(w)
+		}
+	}
+	return ok && len(warnings) == 0
+}
+
+// errorLine pulls the line number out of a "... at line N" error message.
+func errorLine(msg string) int {
+	var line int
+	if idx := strings.LastIndex(msg, "at line "); idx >= 0 {
+		fmt.Sscanf(msg[idx+len("at line "):], "%d", &line)
+	}
+	return line
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLintNameErrors checks that a name the resolver rejects is reported
+// once, in line order with the lint warnings.
+func TestLintNameErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{
+			"skibidi unused rizz 1 ohio\ntypo rizz 2 ohio\n",
+			[]string{"1:unused-variable", "2:undeclared-assignment"},
+		},
+		{
+			"gyatt 1 ohio\nskibidi u rizz 1 ohio\ngyatt missing ohio\n",
+			[]string{"2:unused-variable", "3:name-error"},
+		},
+		{
+			"skibidi x rizz 1 ohio\nskibidi x rizz 2 ohio\nskibidi unused rizz 3 ohio\n",
+			[]string{"1:unused-variable", "2:unused-variable", "2:name-error", "3:unused-variable"},
+		},
+	}
+	for _, test := range tests {
+		program, err := parseProgram(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, w := range lintProgram("test.skibidi", program) {
+			got = append(got, fmt.Sprintf("%d:%s", w.Line, w.Code))
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.code, got, test.want)
+		}
+	}
+}
+
+// TestLintCodes checks each warning code on a small program that should
+// raise it and nothing else.
+func TestLintCodes(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"skibidi x rizz 1 ohio\n", "1:unused-variable:variable x is declared but never read"},
+		{"typo rizz 1 ohio\n", "1:undeclared-assignment:assignment to undeclared variable typo (declare it with skibidi)"},
+		{"sigma helper() {\n    alpha 1 ohio\n}\n", "1:unused-function:function helper is never called"},
+		{"sigma test_helper() {\n    alpha 1 ohio\n}\n", ""},
+		{"sigma add(a, b) {\n    alpha a + b ohio\n}\ngyatt add(1) ohio\n", "4:arg-count:function add expects 2 args, got 1"},
+		{"gyatt len(1, 2) ohio\n", "1:arg-count:len expects 1 argument, got 2"},
+		{"sigma f() {\n    alpha 1 ohio\n    gyatt 2 ohio\n}\ngyatt f() ohio\n", "3:unreachable-code:unreachable code after alpha"},
+		{"bussin (true) {\n    gyatt 1 ohio\n}\n", "1:infinite-loop:bussin (true) loop has no alpha to exit it"},
+		{"skibidi e rizz 1 ohio\ngyatt e ohio\nvibecheck {\n    yeet 1 ohio\n}\ncopium (e) {\n    gyatt 1 ohio\n}\n", ""},
+	}
+	for _, test := range tests {
+		program, err := parseProgram(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, w := range lintProgram("test.skibidi", program) {
+			got = append(got, fmt.Sprintf("%d:%s:%s", w.Line, w.Code, w.Message))
+		}
+		if strings.Join(got, "\n") != test.want {
+			t.Errorf("%q: got %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+// TestLintJSON checks the -json output, including a file that fails to
+// parse.
+func TestLintJSON(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.skibidi")
+	bad := filepath.Join(dir, "bad.skibidi")
+	if err := os.WriteFile(good, []byte("gyatt 1 ohio\nskibidi x rizz 1 ohio\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("gyatt 1 ohio\ngyatt (1 ohio\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var ok bool
+	out := captureStdout(t, func() {
+		ok = runLint([]string{good, bad}, true)
+	})
+	if ok {
+		t.Error("runLint succeeded with warnings")
+	}
+
+	var warnings []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &warnings); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings, want 2:\n%s", len(warnings), out)
+	}
+	want := map[string]interface{}{
+		"file":    good,
+		"line":    2.0,
+		"code":    "unused-variable",
+		"message": "variable x is declared but never read",
+	}
+	if fmt.Sprint(warnings[0]) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", warnings[0], want)
+	}
+	if warnings[1]["file"] != bad || warnings[1]["code"] != "syntax-error" || warnings[1]["line"] != 2.0 {
+		t.Errorf("got %v, want a syntax-error on line 2 of %s", warnings[1], bad)
+	}
+
+}
@@ -0,0 +1,683 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// keywordDocs describes each keyword for hover and completion.
+var keywordDocs = map[string]string{
+	"skibidi": "Variable declaration",
+	"rizz":    "Assignment operator",
+	"cap":     "If statement",
+	"nocap":   "Else statement",
+	"bussin":  "While loop",
+	"gyatt":   "Print statement",
+	"ohio":    "End of statement",
+	"sigma":   "Function definition",
+	"beta":    "Function call",
+	"alpha":   "Return from function",
+	"gyatfor": "For loop",
+	"input":   "Read input from user",
+	"true":    "Boolean true",
+	"false":   "Boolean false",
+	"null":    "No value",
+	"bruh":    "Single-line comment",
+	"import":  "Load another .skibidi file as a module",
+
+	"vibecheck":  "Try block: run code that might fail",
+	"copium":     "Catch block: handle the error a vibecheck raised",
+	"touchgrass": "Finally block: always runs after vibecheck",
+	"yeet":       "Throw an error",
+	"deadass":    "Constant declaration: can't be reassigned",
+}
+
+// LSP completion and symbol kinds used below.
+const (
+	lspCompletionFunction = 3
+	lspCompletionVariable = 6
+	lspCompletionKeyword  = 14
+	lspSymbolFunction     = 12
+	lspSymbolVariable     = 13
+	lspSymbolConstant     = 14
+	lspSeverityError      = 1
+	lspSeverityWarning    = 2
+)
+
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type lspCompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type lspDocumentSymbol struct {
+	Name           string   `json:"name"`
+	Detail         string   `json:"detail,omitempty"`
+	Kind           int      `json:"kind"`
+	Range          lspRange `json:"range"`
+	SelectionRange lspRange `json:"selectionRange"`
+}
+
+// LSPServer answers Language Server Protocol requests for .skibidi files
+// over a JSON-RPC stream, usually stdin and stdout.
+type LSPServer struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]string
+	shutdown bool
+}
+
+func NewLSPServer(in io.Reader, out io.Writer) *LSPServer {
+	return &LSPServer{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]string),
+	}
+}
+
+// readFrame reads one Content-Length framed message body, the transport
+// shared by LSP and DAP.
+func readFrame(in *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		header, err := in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimSpace(header)
+		if header == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(header, "Content-Length:"); ok {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("bad Content-Length: %v", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// writeFrame marshals msg and writes it with a Content-Length header.
+func writeFrame(out io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *LSPServer) readMessage() (*lspMessage, error) {
+	body, err := readFrame(s.in)
+	if err != nil {
+		return nil, err
+	}
+	msg := &lspMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *LSPServer) writeMessage(msg *lspMessage) error {
+	msg.JSONRPC = "2.0"
+	return writeFrame(s.out, msg)
+}
+
+func (s *LSPServer) notify(method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.writeMessage(&lspMessage{Method: method, Params: raw})
+}
+
+// Serve handles messages until the client sends exit or closes the stream.
+func (s *LSPServer) Serve() error {
+	for {
+		msg, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit received before shutdown")
+			}
+			return nil
+		}
+
+		result, rpcErr := s.handle(msg)
+		if msg.ID == nil {
+			continue // notifications get no reply
+		}
+		reply := &lspMessage{ID: msg.ID, Error: rpcErr}
+		if rpcErr == nil {
+			// A null result must still be sent, so marshal it explicitly
+			reply.Result = json.RawMessage("null")
+			if result != nil {
+				reply.Result = result
+			}
+		}
+		if err := s.writeMessage(reply); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *LSPServer) handle(msg *lspMessage) (interface{}, *lspError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // full document on every change
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"completionProvider":     map[string]interface{}{},
+				"documentSymbolProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "skibidi-lsp"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		s.publishDiagnostics(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		s.publishDiagnostics(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         params.TextDocument.URI,
+			"diagnostics": []lspDiagnostic{},
+		})
+		return nil, nil
+	case "textDocument/definition", "textDocument/hover", "textDocument/completion":
+		var params lspTextDocumentPosition
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		text, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		switch msg.Method {
+		case "textDocument/definition":
+			return lspDefinition(params.TextDocument.URI, text, params.Position), nil
+		case "textDocument/hover":
+			return lspHover(text, params.Position), nil
+		}
+		return lspCompletion(text), nil
+	case "textDocument/documentSymbol":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		return lspDocumentSymbols(s.docs[params.TextDocument.URI]), nil
+	}
+
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &lspError{Code: -32601, Message: "method not found: " + msg.Method}
+}
+
+// publishDiagnostics reports the first syntax error, or when the document
+// parses, the first name the program can't use and lint warnings.
+func (s *LSPServer) publishDiagnostics(uri string) {
+	text := s.docs[uri]
+	lines := strings.Split(text, "\n")
+	diagnostics := []lspDiagnostic{}
+
+	program, err := parseProgram(text)
+	if err != nil {
+		diagnostics = append(diagnostics, lspDiagnostic{
+			Range:    lspLineRange(lines, errorLine(err.Error())),
+			Severity: lspSeverityError,
+			Code:     "syntax-error",
+			Source:   "skibidi",
+			Message:  err.Error(),
+		})
+	} else {
+		for _, w := range lintProgram(uri, program) {
+			severity := lspSeverityWarning
+			if w.Code == "name-error" {
+				severity = lspSeverityError
+			}
+			diagnostics = append(diagnostics, lspDiagnostic{
+				Range:    lspLineRange(lines, w.Line),
+				Severity: severity,
+				Code:     w.Code,
+				Source:   "skibidi",
+				Message:  w.Message,
+			})
+		}
+	}
+
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// lspLineRange covers the whole of a 1-based source line.
+func lspLineRange(lines []string, line int) lspRange {
+	if line < 1 {
+		line = 1
+	}
+	end := 0
+	if line <= len(lines) {
+		end = utf16Length(strings.TrimRight(lines[line-1], "\r"))
+	}
+	return lspRange{Start: lspPosition{Line: line - 1}, End: lspPosition{Line: line - 1, Character: end}}
+}
+
+func utf16Length(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// byteOffset converts a UTF-16 character offset within line to bytes.
+func byteOffset(line string, character int) int {
+	units := 0
+	for idx, r := range line {
+		if units >= character {
+			return idx
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// wordAt returns the identifier under pos, if any.
+func wordAt(text string, pos lspPosition) string {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ""
+	}
+	line := lines[pos.Line]
+	start := byteOffset(line, pos.Character)
+	end := start
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdentByte(line[end]) {
+		end++
+	}
+	return line[start:end]
+}
+
+// nameRange finds name as a whole word on a 1-based source line.
+func nameRange(text string, line int, name string) lspRange {
+	lines := strings.Split(text, "\n")
+	r := lspLineRange(lines, line)
+	if line < 1 || line > len(lines) {
+		return r
+	}
+	loc := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).FindStringIndex(lines[line-1])
+	if loc == nil {
+		return r
+	}
+	start := utf16Length(lines[line-1][:loc[0]])
+	return lspRange{
+		Start: lspPosition{Line: line - 1, Character: start},
+		End:   lspPosition{Line: line - 1, Character: start + utf16Length(name)},
+	}
+}
+
+// lspDeclaration is a variable or parameter found while walking the AST.
+// It can be seen from its own line to the last line of the block it is
+// declared in.
+type lspDeclaration struct {
+	name string
+	line int
+	fn   *SigmaFunc // nil for a top-level variable
+	from int
+	to   int
+}
+
+// blockEnd returns the last line of statements, or after if there are none.
+func blockEnd(statements []ASTNode, after int) int {
+	end := after
+	for _, stmt := range statements {
+		line := nodeLine(stmt)
+		switch s := stmt.(type) {
+		case *IfStmt:
+			line = blockEnd(s.ElseBlock, blockEnd(s.ThenBlock, line))
+		case *WhileStmt:
+			line = blockEnd(s.Body, line)
+		case *ForStmt:
+			line = blockEnd(s.Body, line)
+		case *TryStmt:
+			line = blockEnd(s.FinallyBlock, blockEnd(s.CatchBlock, blockEnd(s.Body, line)))
+		case *SigmaFunc:
+			line = s.EndLine
+		}
+		if line > end {
+			end = line
+		}
+	}
+	return end
+}
+
+// collectDeclarations walks statements, a block ending at line end,
+// recording every declaration.
+func collectDeclarations(statements []ASTNode, fn *SigmaFunc, end int, decls []lspDeclaration, functions map[string]*SigmaFunc) []lspDeclaration {
+	declare := func(name string, line int, fn *SigmaFunc, from int, to int) {
+		decls = append(decls, lspDeclaration{name: name, line: line, fn: fn, from: from, to: to})
+	}
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *VarDecl:
+			declare(s.Name, s.Line, fn, s.Line, end)
+		case *IfStmt:
+			decls = collectDeclarations(s.ThenBlock, fn, blockEnd(s.ThenBlock, s.Line), decls, functions)
+			decls = collectDeclarations(s.ElseBlock, fn, blockEnd(s.ElseBlock, s.Line), decls, functions)
+		case *WhileStmt:
+			decls = collectDeclarations(s.Body, fn, blockEnd(s.Body, s.Line), decls, functions)
+		case *ForStmt:
+			loopEnd := blockEnd(s.Body, s.Line)
+			if s.Init != nil {
+				decls = collectDeclarations([]ASTNode{s.Init}, fn, loopEnd, decls, functions)
+			}
+			decls = collectDeclarations(s.Body, fn, loopEnd, decls, functions)
+		case *TryStmt:
+			decls = collectDeclarations(s.Body, fn, blockEnd(s.Body, s.Line), decls, functions)
+			if s.CatchBlock != nil {
+				catchEnd := blockEnd(s.CatchBlock, s.CatchLine)
+				declare(s.CatchName, s.CatchLine, fn, s.CatchLine, catchEnd)
+				decls = collectDeclarations(s.CatchBlock, fn, catchEnd, decls, functions)
+			}
+			decls = collectDeclarations(s.FinallyBlock, fn, blockEnd(s.FinallyBlock, s.Line), decls, functions)
+		case *SigmaFunc:
+			functions[s.Name] = s
+			for _, param := range s.Params {
+				declare(param, s.Line, s, s.Line, s.EndLine)
+			}
+			decls = collectDeclarations(s.Body, s, s.EndLine, decls, functions)
+		}
+	}
+	return decls
+}
+
+// findDeclaration picks the innermost declaration of name in scope at
+// line, in the function the cursor is in or at the top level.
+func findDeclaration(decls []lspDeclaration, name string, line int) *lspDeclaration {
+	// The enclosing function is the innermost one whose body spans line
+	var enclosing *SigmaFunc
+	for _, d := range decls {
+		if d.fn != nil && d.fn.Line <= line && line <= d.fn.EndLine && (enclosing == nil || d.fn.Line > enclosing.Line) {
+			enclosing = d.fn
+		}
+	}
+
+	// Scopes nest, so the one seen latest is the innermost
+	var best *lspDeclaration
+	for idx := range decls {
+		d := &decls[idx]
+		if d.name != name || line < d.from || line > d.to || (d.fn != nil && d.fn != enclosing) {
+			continue
+		}
+		if best == nil || d.from > best.from {
+			best = d
+		}
+	}
+	if best != nil || enclosing == nil {
+		return best
+	}
+	// Functions can read globals declared further down the file
+	for idx := range decls {
+		if decls[idx].name == name && decls[idx].fn == nil && decls[idx].to == math.MaxInt {
+			return &decls[idx]
+		}
+	}
+	return nil
+}
+
+func lspDefinition(uri, text string, pos lspPosition) interface{} {
+	name := wordAt(text, pos)
+	if name == "" {
+		return nil
+	}
+	program, err := parseProgram(text)
+	if err != nil {
+		return nil
+	}
+	functions := make(map[string]*SigmaFunc)
+	decls := collectDeclarations(program.Statements, nil, math.MaxInt, nil, functions)
+
+	if d := findDeclaration(decls, name, pos.Line+1); d != nil {
+		return lspLocation{URI: uri, Range: nameRange(text, d.line, name)}
+	}
+	if fn, ok := functions[name]; ok {
+		return lspLocation{URI: uri, Range: nameRange(text, fn.Line, name)}
+	}
+	return nil
+}
+
+func functionSignature(fn *SigmaFunc) string {
+	return fmt.Sprintf("sigma %s(%s)", fn.Name, strings.Join(fn.Params, ", "))
+}
+
+func lspHover(text string, pos lspPosition) interface{} {
+	name := wordAt(text, pos)
+	if name == "" {
+		return nil
+	}
+
+	contents := ""
+	if doc, ok := keywordDocs[name]; ok {
+		contents = fmt.Sprintf("**%s** — %s", name, doc)
+	} else if program, err := parseProgram(text); err == nil {
+		functions := make(map[string]*SigmaFunc)
+		decls := collectDeclarations(program.Statements, nil, math.MaxInt, nil, functions)
+		if d := findDeclaration(decls, name, pos.Line+1); d != nil {
+			if d.fn != nil && d.line == d.fn.Line {
+				contents = fmt.Sprintf("```skibidi\n%s\n```\nParameter of %s", name, d.fn.Name)
+			} else {
+				contents = fmt.Sprintf("```skibidi\nskibidi %s\n```\nDeclared on line %d", name, d.line)
+			}
+		} else if fn, ok := functions[name]; ok {
+			contents = fmt.Sprintf("```skibidi\n%s\n```", functionSignature(fn))
+		}
+	}
+	// A sigma of the same name is called instead of the built-in
+	if bounds, ok := builtinArgs[name]; ok && contents == "" {
+		contents = fmt.Sprintf("```skibidi\n%s(...)\n```\nBuilt-in function, expects %s", name, describeArgCount(bounds))
+	}
+	if contents == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"contents": map[string]string{"kind": "markdown", "value": contents},
+	}
+}
+
+func lspCompletion(text string) interface{} {
+	items := []lspCompletionItem{}
+	for _, kw := range sortedKeys(keywordDocs) {
+		items = append(items, lspCompletionItem{Label: kw, Kind: lspCompletionKeyword, Detail: keywordDocs[kw]})
+	}
+	for _, name := range sortedKeys(builtinArgs) {
+		items = append(items, lspCompletionItem{Label: name, Kind: lspCompletionFunction, Detail: "built-in"})
+	}
+	for _, name := range sortedKeys(builtinConstants) {
+		items = append(items, lspCompletionItem{Label: name, Kind: lspCompletionVariable, Detail: "built-in constant"})
+	}
+
+	// Completion has to work while the user is mid-statement, so fall back
+	// to keywords alone when the document doesn't parse
+	if program, err := parseProgram(text); err == nil {
+		functions := make(map[string]*SigmaFunc)
+		decls := collectDeclarations(program.Statements, nil, math.MaxInt, nil, functions)
+		seen := make(map[string]bool)
+		for _, d := range decls {
+			if !seen[d.name] {
+				seen[d.name] = true
+				items = append(items, lspCompletionItem{Label: d.name, Kind: lspCompletionVariable})
+			}
+		}
+		for _, name := range sortedKeys(functions) {
+			items = append(items, lspCompletionItem{Label: name, Kind: lspCompletionFunction, Detail: functionSignature(functions[name])})
+		}
+	}
+	return items
+}
+
+func lspDocumentSymbols(text string) interface{} {
+	symbols := []lspDocumentSymbol{}
+	program, err := parseProgram(text)
+	if err != nil {
+		return symbols
+	}
+	lines := strings.Split(text, "\n")
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *SigmaFunc:
+			symbols = append(symbols, lspDocumentSymbol{
+				Name:           s.Name,
+				Detail:         functionSignature(s),
+				Kind:           lspSymbolFunction,
+				Range:          lspLineRange(lines, s.Line),
+				SelectionRange: nameRange(text, s.Line, s.Name),
+			})
+		case *VarDecl:
+			kind := lspSymbolVariable
+			if s.Constant {
+				kind = lspSymbolConstant
+			}
+			symbols = append(symbols, lspDocumentSymbol{
+				Name:           s.Name,
+				Kind:           kind,
+				Range:          lspLineRange(lines, s.Line),
+				SelectionRange: nameRange(text, s.Line, s.Name),
+			})
+		}
+	}
+	return symbols
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runLSP serves the language server on stdin and stdout.
+func runLSP() {
+	server := NewLSPServer(os.Stdin, os.Stdout)
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "skibidi lsp: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lspClient drives an LSPServer over pipes the way an editor would.
+type lspClient struct {
+	t      *testing.T
+	toSrv  *io.PipeWriter
+	fromSv *bufio.Reader
+	nextID int
+	done   chan error
+}
+
+func startLSP(t *testing.T) *lspClient {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	c := &lspClient{t: t, toSrv: clientOut, fromSv: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewLSPServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+	}()
+	return c
+}
+
+func (c *lspClient) send(msg map[string]interface{}) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.toSrv, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *lspClient) read() map[string]interface{} {
+	c.t.Helper()
+	length := 0
+	for {
+		line, err := c.fromSv.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("reading header: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "Content-Length: "); ok {
+			length, _ = strconv.Atoi(value)
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.fromSv, body); err != nil {
+		c.t.Fatalf("reading body: %v", err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+func (c *lspClient) notify(method string, params interface{}) {
+	c.send(map[string]interface{}{"method": method, "params": params})
+}
+
+// request sends a request and returns its result, skipping notifications.
+func (c *lspClient) request(method string, params interface{}) interface{} {
+	c.t.Helper()
+	c.nextID++
+	c.send(map[string]interface{}{"id": c.nextID, "method": method, "params": params})
+	for {
+		msg := c.read()
+		if id, ok := msg["id"]; ok && id == float64(c.nextID) {
+			if msg["error"] != nil {
+				c.t.Fatalf("%s failed: %v", method, msg["error"])
+			}
+			return msg["result"]
+		}
+	}
+}
+
+func position(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": line, "character": character},
+	}
+}
+
+const lspSample = `sigma add(a, b) {
+    alpha a + b ohio
+}
+skibidi total rizz beta add(1, 2) ohio
+gyatt total ohio
+`
+
+func TestLSPSession(t *testing.T) {
+	c := startLSP(t)
+	uri := "file:///sample.skibidi"
+
+	result := c.request("initialize", map[string]interface{}{})
+	caps := result.(map[string]interface{})["capabilities"].(map[string]interface{})
+	if caps["definitionProvider"] != true || caps["hoverProvider"] != true {
+		t.Fatalf("missing capabilities: %v", caps)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	// A broken document reports a syntax error on the right line
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "skibidi", "version": 1, "text": "gyatt 1 ohio\ngyatt ( ohio\n"},
+	})
+	diag := c.read()
+	if diag["method"] != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected diagnostics, got %v", diag)
+	}
+	diags := diag["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diags)
+	}
+	line := diags[0].(map[string]interface{})["range"].(map[string]interface{})["start"].(map[string]interface{})["line"]
+	if line != float64(1) {
+		t.Errorf("syntax error reported on line %v, want 1", line)
+	}
+
+	// Fixing it clears the diagnostics
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": lspSample}},
+	})
+	diag = c.read()
+	if diags := diag["params"].(map[string]interface{})["diagnostics"].([]interface{}); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+
+	// Go to definition of add from its call site
+	loc := c.request("textDocument/definition", position(uri, 3, 25)).(map[string]interface{})
+	start := loc["range"].(map[string]interface{})["start"].(map[string]interface{})
+	if start["line"] != float64(0) || start["character"] != float64(6) {
+		t.Errorf("definition of add at %v, want 0:6", start)
+	}
+
+	// Go to definition of a variable
+	loc = c.request("textDocument/definition", position(uri, 4, 7)).(map[string]interface{})
+	start = loc["range"].(map[string]interface{})["start"].(map[string]interface{})
+	if start["line"] != float64(3) || start["character"] != float64(8) {
+		t.Errorf("definition of total at %v, want 3:8", start)
+	}
+
+	hover := c.request("textDocument/hover", position(uri, 3, 25)).(map[string]interface{})
+	value := hover["contents"].(map[string]interface{})["value"].(string)
+	if !strings.Contains(value, "sigma add(a, b)") {
+		t.Errorf("hover = %q, want the add signature", value)
+	}
+
+	labels := map[string]bool{}
+	for _, item := range c.request("textDocument/completion", position(uri, 4, 0)).([]interface{}) {
+		labels[item.(map[string]interface{})["label"].(string)] = true
+	}
+	for _, want := range []string{"skibidi", "gyatt", "nocap", "len", "add", "total"} {
+		if !labels[want] {
+			t.Errorf("completion is missing %s", want)
+		}
+	}
+
+	var names []string
+	for _, sym := range c.request("textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+	}).([]interface{}) {
+		names = append(names, sym.(map[string]interface{})["name"].(string))
+	}
+	if strings.Join(names, ",") != "add,total" {
+		t.Errorf("document symbols = %v, want [add total]", names)
+	}
+
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Fatalf("server exited with %v", err)
+	}
+}
+
+// TestLSPDefinitionAfterSigma checks that a global used after a sigma
+// isn't taken for the sigma's parameter of the same name.
+func TestLSPDefinitionAfterSigma(t *testing.T) {
+	text := "sigma f(x) {\n    alpha x ohio\n}\nskibidi x rizz 1 ohio\ngyatt x ohio\n"
+	for _, test := range []struct{ line, char, want int }{
+		{1, 10, 0}, // x in the body is the parameter
+		{4, 6, 3},  // x after the body is the global
+	} {
+		loc, ok := lspDefinition("file:///f.skibidi", text, lspPosition{Line: test.line, Character: test.char}).(lspLocation)
+		if !ok || loc.Range.Start.Line != test.want {
+			t.Errorf("definition at %d:%d is %v, want line %d", test.line, test.char, loc, test.want)
+		}
+	}
+}
+
+// TestLSPDefinitionInBlocks checks that a declaration inside a block is
+// only found from inside that block, where it hides the outer one.
+func TestLSPDefinitionInBlocks(t *testing.T) {
+	text := `skibidi x rizz 1 ohio
+cap (true) {
+    skibidi x rizz 2 ohio
+    gyatt x ohio
+}
+gyatt x ohio
+gyatfor (skibidi i rizz 0; i < 1; i rizz i + 1) {
+    skibidi x rizz i ohio
+}
+vibecheck {
+    gyatt x ohio
+}
+copium (x) {
+    gyatt x ohio
+}
+sigma f() {
+    cap (true) {
+        skibidi x rizz 3 ohio
+    }
+    alpha x ohio
+}
+gyatt x ohio
+`
+	for _, test := range []struct{ line, char, want int }{
+		{3, 10, 2},  // inside the cap block: its own x
+		{5, 6, 0},   // after the block: the outer x again
+		{10, 10, 0}, // in vibecheck, before copium declares its x
+		{13, 10, 12},
+		{19, 10, 0}, // a sigma sees the global, not its block's x
+		{21, 6, 0},
+	} {
+		loc, ok := lspDefinition("file:///f.skibidi", text, lspPosition{Line: test.line, Character: test.char}).(lspLocation)
+		if !ok || loc.Range.Start.Line != test.want {
+			t.Errorf("definition at %d:%d is %v, want line %d", test.line, test.char, loc, test.want)
+		}
+	}
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Token types
@@ -50,8 +54,40 @@ const (
 	TRUE
 	FALSE
 	COMMA
+	IMPORT
+	DOT
+	LBRACKET
+	RBRACKET
+	VIBECHECK  // try
+	COPIUM     // catch
+	TOUCHGRASS // finally
+	YEET       // throw
+	NULL
+	DEADASS // constant declaration
 )
 
+var tokenNames = [...]string{
+	SKIBIDI: "SKIBIDI", RIZZ: "RIZZ", CAP: "CAP", NOCAP: "NOCAP", BUSSIN: "BUSSIN",
+	GYATT: "GYATT", OHIO: "OHIO", SIGMA: "SIGMA", ALPHA: "ALPHA", BETA: "BETA",
+	BRUH: "BRUH", NUMBER: "NUMBER", STRING: "STRING", IDENTIFIER: "IDENTIFIER",
+	PLUS: "PLUS", MINUS: "MINUS", MULTIPLY: "MULTIPLY", DIVIDE: "DIVIDE",
+	EQUALS: "EQUALS", LESS_THAN: "LESS_THAN", GREATER_THAN: "GREATER_THAN",
+	LESS_EQUAL: "LESS_EQUAL", GREATER_EQUAL: "GREATER_EQUAL", LPAREN: "LPAREN",
+	RPAREN: "RPAREN", LBRACE: "LBRACE", RBRACE: "RBRACE", SEMICOLON: "SEMICOLON",
+	EOF: "EOF", MODULO: "MODULO", AND: "AND", OR: "OR", FOR: "FOR", INPUT: "INPUT",
+	TRUE: "TRUE", FALSE: "FALSE", COMMA: "COMMA", IMPORT: "IMPORT", DOT: "DOT",
+	LBRACKET: "LBRACKET", RBRACKET: "RBRACKET", VIBECHECK: "VIBECHECK",
+	COPIUM: "COPIUM", TOUCHGRASS: "TOUCHGRASS", YEET: "YEET", NULL: "NULL",
+	DEADASS: "DEADASS",
+}
+
+func (t TokenType) String() string {
+	if int(t) < len(tokenNames) {
+		return tokenNames[t]
+	}
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
 type Token struct {
 	Type  TokenType
 	Value string
@@ -59,9 +95,11 @@ type Token struct {
 }
 
 type Lexer struct {
-	input    string
-	position int
-	line     int
+	input        string
+	position     int
+	line         int
+	keepComments bool // return comments as BRUH tokens instead of skipping them
+	unterminated bool // the last string ran to the end of the input
 }
 
 func NewLexer(input string) *Lexer {
@@ -126,6 +164,8 @@ func (l *Lexer) readString() string {
 
 	if l.peek() == '"' {
 		l.advance() // skip closing quote
+	} else {
+		l.unterminated = true
 	}
 
 	return result.String()
@@ -170,9 +210,13 @@ func (l *Lexer) NextToken() Token {
 
 	// Single-line comments starting with "bruh"
 	if l.position+4 <= len(l.input) && l.input[l.position:l.position+4] == "bruh" {
+		start := l.position
 		for l.peek() != '\n' && l.peek() != 0 {
 			l.advance()
 		}
+		if l.keepComments {
+			return Token{BRUH, strings.TrimRight(l.input[start:l.position], " \t\r"), l.line}
+		}
 		return l.NextToken()
 	}
 
@@ -192,6 +236,14 @@ func (l *Lexer) NextToken() Token {
 		"input":   INPUT,
 		"true":    TRUE,
 		"false":   FALSE,
+		"null":    NULL,
+		"import":  IMPORT,
+
+		"vibecheck":  VIBECHECK,
+		"copium":     COPIUM,
+		"touchgrass": TOUCHGRASS,
+		"yeet":       YEET,
+		"deadass":    DEADASS,
 	}
 
 	if (l.peek() >= 'a' && l.peek() <= 'z') || (l.peek() >= 'A' && l.peek() <= 'Z') {
@@ -199,10 +251,6 @@ func (l *Lexer) NextToken() Token {
 		if tokenType, exists := keywords[identifier]; exists {
 			return Token{tokenType, identifier, l.line}
 		}
-		// Built-in functions: len, abs, str
-		if identifier == "len" || identifier == "abs" || identifier == "str" {
-			return Token{IDENTIFIER, identifier, l.line}
-		}
 		return Token{IDENTIFIER, identifier, l.line}
 	}
 
@@ -285,6 +333,15 @@ func (l *Lexer) NextToken() Token {
 	case ',':
 		l.advance()
 		return Token{COMMA, ",", l.line}
+	case '.':
+		l.advance()
+		return Token{DOT, ".", l.line}
+	case '[':
+		l.advance()
+		return Token{LBRACKET, "[", l.line}
+	case ']':
+		l.advance()
+		return Token{RBRACKET, "]", l.line}
 	default:
 		ch := l.advance()
 		return Token{EOF, string(ch), l.line}
@@ -305,8 +362,11 @@ func (p *Program) String() string {
 }
 
 type VarDecl struct {
-	Name  string
-	Value ASTNode
+	Name     string
+	Value    ASTNode
+	Constant bool // declared with deadass
+	Line     int
+	Slot     int // in the scope it declares into, set by the resolver
 }
 
 func (v *VarDecl) String() string {
@@ -316,6 +376,8 @@ func (v *VarDecl) String() string {
 type Assignment struct {
 	Name  string
 	Value ASTNode
+	Line  int
+	Var   Binding // set by the resolver
 }
 
 func (a *Assignment) String() string {
@@ -324,6 +386,7 @@ func (a *Assignment) String() string {
 
 type PrintStmt struct {
 	Value ASTNode
+	Line  int
 }
 
 func (p *PrintStmt) String() string {
@@ -334,6 +397,7 @@ type IfStmt struct {
 	Condition ASTNode
 	ThenBlock []ASTNode
 	ElseBlock []ASTNode
+	Line      int
 }
 
 func (i *IfStmt) String() string {
@@ -343,6 +407,7 @@ func (i *IfStmt) String() string {
 type WhileStmt struct {
 	Condition ASTNode
 	Body      []ASTNode
+	Line      int
 }
 
 func (w *WhileStmt) String() string {
@@ -353,6 +418,7 @@ type BinaryOp struct {
 	Left     ASTNode
 	Operator string
 	Right    ASTNode
+	Line     int
 }
 
 func (b *BinaryOp) String() string {
@@ -361,6 +427,7 @@ func (b *BinaryOp) String() string {
 
 type NumberLiteral struct {
 	Value float64
+	Line  int
 }
 
 func (n *NumberLiteral) String() string {
@@ -369,6 +436,7 @@ func (n *NumberLiteral) String() string {
 
 type StringLiteral struct {
 	Value string
+	Line  int
 }
 
 func (s *StringLiteral) String() string {
@@ -376,7 +444,10 @@ func (s *StringLiteral) String() string {
 }
 
 type Identifier struct {
-	Name string
+	Module string // set for mod.name
+	Name   string
+	Line   int
+	Var    Binding // set by the resolver
 }
 
 func (i *Identifier) String() string {
@@ -384,9 +455,11 @@ func (i *Identifier) String() string {
 }
 
 type SigmaFunc struct {
-	Name   string
-	Params []string
-	Body   []ASTNode
+	Name    string
+	Params  []string
+	Body    []ASTNode
+	Line    int
+	EndLine int // line of the closing brace
 }
 
 func (f *SigmaFunc) String() string {
@@ -394,8 +467,11 @@ func (f *SigmaFunc) String() string {
 }
 
 type BetaCall struct {
-	Name string
-	Args []ASTNode
+	Module string // set for mod.name(...)
+	Name   string
+	Args   []ASTNode
+	Line   int
+	Beta   bool // written with the beta keyword
 }
 
 func (b *BetaCall) String() string {
@@ -404,24 +480,83 @@ func (b *BetaCall) String() string {
 
 type AlphaReturn struct {
 	Value ASTNode
+	Line  int
 }
 
 func (a *AlphaReturn) String() string {
 	return "AlphaReturn"
 }
 
+// TryStmt is vibecheck { } copium (e) { } touchgrass { }. Either the
+// copium or the touchgrass part can be left out, but not both.
+type TryStmt struct {
+	Body         []ASTNode
+	CatchName    string
+	CatchLine    int       // where copium names the error
+	CatchBlock   []ASTNode // nil without copium
+	FinallyBlock []ASTNode // nil without touchgrass
+	Line         int
+}
+
+func (t *TryStmt) String() string {
+	return "TryStmt"
+}
+
+// ThrowStmt is yeet value ohio.
+type ThrowStmt struct {
+	Value ASTNode
+	Line  int
+}
+
+func (t *ThrowStmt) String() string {
+	return "ThrowStmt"
+}
+
 type ForStmt struct {
 	Init      ASTNode
 	Condition ASTNode
 	Post      ASTNode
 	Body      []ASTNode
+	Line      int
 }
 
 func (f *ForStmt) String() string {
 	return "ForStmt"
 }
 
-type InputExpr struct{}
+type ListLiteral struct {
+	Elements []ASTNode
+	Line     int
+}
+
+func (l *ListLiteral) String() string {
+	return fmt.Sprintf("List(%d)", len(l.Elements))
+}
+
+// IndexExpr is target[index] on a string or list.
+type IndexExpr struct {
+	Target ASTNode
+	Index  ASTNode
+	Line   int
+}
+
+func (i *IndexExpr) String() string {
+	return "Index"
+}
+
+type ImportStmt struct {
+	Path string
+	Name string // namespace the module's names are reached through
+	Line int
+}
+
+func (i *ImportStmt) String() string {
+	return fmt.Sprintf("ImportStmt(%s)", i.Path)
+}
+
+type InputExpr struct {
+	Line int
+}
 
 func (i *InputExpr) String() string {
 	return "InputExpr"
@@ -429,28 +564,187 @@ func (i *InputExpr) String() string {
 
 type BoolLiteral struct {
 	Value bool
+	Line  int
+}
+
+type NullLiteral struct {
+	Line int
+}
+
+func (n *NullLiteral) String() string {
+	return "Null"
 }
 
 func (b *BoolLiteral) String() string {
 	return fmt.Sprintf("Bool(%v)", b.Value)
 }
 
+// nodeLine returns the source line a node starts on.
+func nodeLine(node ASTNode) int {
+	switch n := node.(type) {
+	case *VarDecl:
+		return n.Line
+	case *Assignment:
+		return n.Line
+	case *PrintStmt:
+		return n.Line
+	case *IfStmt:
+		return n.Line
+	case *WhileStmt:
+		return n.Line
+	case *ForStmt:
+		return n.Line
+	case *SigmaFunc:
+		return n.Line
+	case *BetaCall:
+		return n.Line
+	case *AlphaReturn:
+		return n.Line
+	case *TryStmt:
+		return n.Line
+	case *ThrowStmt:
+		return n.Line
+	case *ImportStmt:
+		return n.Line
+	case *BinaryOp:
+		return nodeLine(n.Left)
+	case *NumberLiteral:
+		return n.Line
+	case *StringLiteral:
+		return n.Line
+	case *BoolLiteral:
+		return n.Line
+	case *NullLiteral:
+		return n.Line
+	case *Identifier:
+		return n.Line
+	case *InputExpr:
+		return n.Line
+	case *ListLiteral:
+		return n.Line
+	case *IndexExpr:
+		return nodeLine(n.Target)
+	}
+	return 0
+}
+
+// Comment is a bruh comment kept by a lexer in keepComments mode. Trailing
+// comments sit on the same line as the code before them; Closing ones
+// follow the closing brace of the block they are in.
+type Comment struct {
+	Text     string
+	Trailing bool
+	Closing  bool
+}
+
+func (c *Comment) String() string {
+	return fmt.Sprintf("Comment(%s)", c.Text)
+}
+
+// CommentedExpr is an expression with the comments written around it in
+// the middle of a statement, kept in keepComments mode so the formatter
+// can leave them where they were.
+type CommentedExpr struct {
+	Before []*Comment // between the token before the expression and it
+	Expr   ASTNode
+	After  []*Comment // between the expression and the token after it
+}
+
+func (c *CommentedExpr) String() string {
+	return fmt.Sprintf("CommentedExpr(%v)", c.Expr)
+}
+
+// BlankLine marks an empty line between statements in keepComments mode.
+type BlankLine struct{}
+
+func (b *BlankLine) String() string {
+	return "BlankLine"
+}
+
 // Parser
 type Parser struct {
 	lexer        *Lexer
 	currentToken Token
+	lastLine     int       // line of the last token eaten
+	triviaLine   int       // line of the last token or comment seen
+	comments     []ASTNode // comments waiting to be placed in a statement list
 }
 
 func NewParser(lexer *Lexer) *Parser {
 	parser := &Parser{lexer: lexer}
-	parser.currentToken = parser.lexer.NextToken()
+	parser.currentToken = parser.nextToken()
 	return parser
 }
 
+// nextToken reads the next token, setting aside any comments on the way.
+func (p *Parser) nextToken() Token {
+	token := p.lexer.NextToken()
+	for token.Type == BRUH {
+		if token.Line > p.triviaLine+1 {
+			p.comments = append(p.comments, &BlankLine{})
+		}
+		trailing := p.lastLine > 0 && token.Line == p.lastLine
+		p.comments = append(p.comments, &Comment{Text: token.Value, Trailing: trailing})
+		p.triviaLine = token.Line
+		token = p.lexer.NextToken()
+	}
+	return token
+}
+
+// takeTrivia appends pending comments, and a blank line if the next
+// statement is separated from what came before, to statements.
+func (p *Parser) takeTrivia(statements []ASTNode) []ASTNode {
+	if !p.lexer.keepComments {
+		return statements
+	}
+	statements = append(statements, p.comments...)
+	p.comments = nil
+	if p.currentToken.Type != RBRACE && p.currentToken.Type != EOF && p.currentToken.Line > p.triviaLine+1 {
+		statements = append(statements, &BlankLine{})
+	}
+	return statements
+}
+
+// takeComments returns the comments read since the last token, leaving out
+// blank lines, for the parts of a statement that can hold them.
+func (p *Parser) takeComments() []*Comment {
+	var comments []*Comment
+	for _, node := range p.comments {
+		if c, ok := node.(*Comment); ok {
+			comments = append(comments, c)
+		}
+	}
+	p.comments = nil
+	return comments
+}
+
+// IncompleteInputError is panicked when the input ends partway through a
+// statement, so the REPL can tell it apart from a real syntax error and
+// read another line.
+type IncompleteInputError struct {
+	Line int
+}
+
+func (e *IncompleteInputError) Error() string {
+	return fmt.Sprintf("Unexpected end of input at line %d", e.Line)
+}
+
+// failAtEnd panics with an IncompleteInputError if the input has run out.
+// The lexer also uses EOF for characters it doesn't know, but only a real
+// end of input has an empty value.
+func (p *Parser) failAtEnd() {
+	if p.currentToken.Type == EOF && p.currentToken.Value == "" {
+		panic(&IncompleteInputError{Line: p.currentToken.Line})
+	}
+}
+
 func (p *Parser) eat(expectedType TokenType) {
 	if p.currentToken.Type == expectedType {
-		p.currentToken = p.lexer.NextToken()
+		p.lastLine = p.currentToken.Line
+		p.triviaLine = p.currentToken.Line
+		p.currentToken = p.nextToken()
 	} else {
+		p.failAtEnd()
 		panic(fmt.Sprintf("Expected token %d, got %d at line %d", expectedType, p.currentToken.Type, p.currentToken.Line))
 	}
 }
@@ -464,9 +758,10 @@ func (p *Parser) parseLogicalOr() ASTNode {
 
 	for p.currentToken.Type == OR {
 		op := p.currentToken.Value
+		line := p.currentToken.Line
 		p.eat(OR)
 		right := p.parseLogicalAnd()
-		node = &BinaryOp{Left: node, Operator: op, Right: right}
+		node = &BinaryOp{Left: node, Operator: op, Right: right, Line: line}
 	}
 
 	return node
@@ -477,9 +772,10 @@ func (p *Parser) parseLogicalAnd() ASTNode {
 
 	for p.currentToken.Type == AND {
 		op := p.currentToken.Value
+		line := p.currentToken.Line
 		p.eat(AND)
 		right := p.parseComparison()
-		node = &BinaryOp{Left: node, Operator: op, Right: right}
+		node = &BinaryOp{Left: node, Operator: op, Right: right, Line: line}
 	}
 
 	return node
@@ -490,9 +786,10 @@ func (p *Parser) parseComparison() ASTNode {
 
 	for p.currentToken.Type == EQUALS || p.currentToken.Type == LESS_THAN || p.currentToken.Type == GREATER_THAN || p.currentToken.Type == LESS_EQUAL || p.currentToken.Type == GREATER_EQUAL {
 		op := p.currentToken.Value
+		line := p.currentToken.Line
 		p.eat(p.currentToken.Type)
 		right := p.parseArithmetic()
-		node = &BinaryOp{Left: node, Operator: op, Right: right}
+		node = &BinaryOp{Left: node, Operator: op, Right: right, Line: line}
 	}
 
 	return node
@@ -503,9 +800,10 @@ func (p *Parser) parseArithmetic() ASTNode {
 
 	for p.currentToken.Type == PLUS || p.currentToken.Type == MINUS {
 		op := p.currentToken.Value
+		line := p.currentToken.Line
 		p.eat(p.currentToken.Type)
 		right := p.parseTerm()
-		node = &BinaryOp{Left: node, Operator: op, Right: right}
+		node = &BinaryOp{Left: node, Operator: op, Right: right, Line: line}
 	}
 
 	return node
@@ -516,96 +814,162 @@ func (p *Parser) parseTerm() ASTNode {
 
 	for p.currentToken.Type == MULTIPLY || p.currentToken.Type == DIVIDE || p.currentToken.Type == MODULO {
 		op := p.currentToken.Value
+		line := p.currentToken.Line
 		p.eat(p.currentToken.Type)
 		right := p.parseFactor()
-		node = &BinaryOp{Left: node, Operator: op, Right: right}
+		node = &BinaryOp{Left: node, Operator: op, Right: right, Line: line}
 	}
 
 	return node
 }
 
+// parseFactor parses a primary expression and any [index] after it.
 func (p *Parser) parseFactor() ASTNode {
+	node := p.parsePrimary()
+	for p.currentToken.Type == LBRACKET {
+		line := p.currentToken.Line
+		p.eat(LBRACKET)
+		index := p.parseExpression()
+		p.eat(RBRACKET)
+		node = &IndexExpr{Target: node, Index: index, Line: line}
+	}
+	return node
+}
+
+// parsePrimary parses an operand, keeping any comments around it when the
+// lexer keeps comments.
+func (p *Parser) parsePrimary() ASTNode {
+	before := p.takeComments()
+	node := p.parseOperand()
+	after := p.takeComments()
+	if before == nil && after == nil {
+		return node
+	}
+	return &CommentedExpr{Before: before, Expr: node, After: after}
+}
+
+func (p *Parser) parseOperand() ASTNode {
 	token := p.currentToken
 
 	if token.Type == NUMBER {
 		p.eat(NUMBER)
 		value, _ := strconv.ParseFloat(token.Value, 64)
-		return &NumberLiteral{Value: value}
+		return &NumberLiteral{Value: value, Line: token.Line}
 	} else if token.Type == STRING {
+		if p.lexer.unterminated {
+			// The string swallowed the rest of the input, so more may follow
+			panic(&IncompleteInputError{Line: token.Line})
+		}
 		p.eat(STRING)
-		return &StringLiteral{Value: token.Value}
+		return &StringLiteral{Value: token.Value, Line: token.Line}
 	} else if token.Type == TRUE {
 		p.eat(TRUE)
-		return &BoolLiteral{Value: true}
+		return &BoolLiteral{Value: true, Line: token.Line}
 	} else if token.Type == FALSE {
 		p.eat(FALSE)
-		return &BoolLiteral{Value: false}
+		return &BoolLiteral{Value: false, Line: token.Line}
+	} else if token.Type == NULL {
+		p.eat(NULL)
+		return &NullLiteral{Line: token.Line}
 	} else if token.Type == IDENTIFIER {
-		name := token.Value
-		p.eat(IDENTIFIER)
+		module, name := p.parseQualifiedName()
 		if p.currentToken.Type == LPAREN {
 			// Built-in or user function call
-			p.eat(LPAREN)
-			args := []ASTNode{}
-			if p.currentToken.Type != RPAREN {
-				args = append(args, p.parseExpression())
-				for p.currentToken.Type == COMMA {
-					p.eat(COMMA)
-					args = append(args, p.parseExpression())
-				}
-			}
-			p.eat(RPAREN)
-			return &BetaCall{Name: name, Args: args}
+			args := p.parseCallArgs()
+			return &BetaCall{Module: module, Name: name, Args: args, Line: token.Line}
 		}
-		return &Identifier{Name: name}
+		return &Identifier{Module: module, Name: name, Line: token.Line}
 	} else if token.Type == INPUT {
 		p.eat(INPUT)
-		return &InputExpr{}
+		return &InputExpr{Line: token.Line}
 	} else if token.Type == BETA {
 		p.eat(BETA)
-		name := p.currentToken.Value
-		p.eat(IDENTIFIER)
-		p.eat(LPAREN)
-		args := []ASTNode{}
-		if p.currentToken.Type != RPAREN {
-			args = append(args, p.parseExpression())
-			for p.currentToken.Type == COMMA {
-				p.eat(COMMA)
-				args = append(args, p.parseExpression())
-			}
-		}
-		p.eat(RPAREN)
-		return &BetaCall{Name: name, Args: args}
+		module, name := p.parseQualifiedName()
+		args := p.parseCallArgs()
+		return &BetaCall{Module: module, Name: name, Args: args, Line: token.Line, Beta: true}
 	} else if token.Type == MINUS {
 		p.eat(MINUS)
 		factor := p.parseFactor()
-		return &BinaryOp{Left: &NumberLiteral{Value: 0}, Operator: "-", Right: factor}
+		return &BinaryOp{Left: &NumberLiteral{Value: 0, Line: token.Line}, Operator: "-", Right: factor, Line: token.Line}
 	} else if token.Type == LPAREN {
 		p.eat(LPAREN)
 		node := p.parseExpression()
 		p.eat(RPAREN)
 		return node
+	} else if token.Type == LBRACKET {
+		p.eat(LBRACKET)
+		elements := []ASTNode{}
+		if p.currentToken.Type != RBRACKET {
+			elements = append(elements, p.parseExpression())
+			for p.currentToken.Type == COMMA {
+				p.eat(COMMA)
+				elements = append(elements, p.parseExpression())
+			}
+		}
+		p.eat(RBRACKET)
+		return &ListLiteral{Elements: elements, Line: token.Line}
 	}
 
+	p.failAtEnd()
 	panic(fmt.Sprintf("Unexpected token %s at line %d", token.Value, token.Line))
 }
 
+// parseQualifiedName parses a name, or mod.name for something imported.
+func (p *Parser) parseQualifiedName() (module string, name string) {
+	name = p.currentToken.Value
+	p.eat(IDENTIFIER)
+	if p.currentToken.Type == DOT {
+		p.eat(DOT)
+		module, name = name, p.currentToken.Value
+		p.eat(IDENTIFIER)
+	}
+	return module, name
+}
+
+// parseCallArgs parses a parenthesized, comma-separated argument list.
+func (p *Parser) parseCallArgs() []ASTNode {
+	p.eat(LPAREN)
+	args := []ASTNode{}
+	if p.currentToken.Type != RPAREN {
+		args = append(args, p.parseExpression())
+		for p.currentToken.Type == COMMA {
+			p.eat(COMMA)
+			args = append(args, p.parseExpression())
+		}
+	}
+	p.eat(RPAREN)
+	return args
+}
+
 func (p *Parser) parseBlock() []ASTNode {
 	statements := []ASTNode{}
 	p.eat(LBRACE)
 
 	for p.currentToken.Type != RBRACE && p.currentToken.Type != EOF {
+		statements = p.takeTrivia(statements)
 		stmt := p.parseStatement()
 		statements = append(statements, stmt)
 	}
+	statements = p.takeTrivia(statements)
 
 	p.eat(RBRACE)
+	// Comments after the brace stay with it, even when nocap or copium
+	// carries on the statement
+	for len(p.comments) > 0 {
+		c, ok := p.comments[0].(*Comment)
+		if !ok || !c.Trailing {
+			break
+		}
+		c.Closing = true
+		statements = append(statements, c)
+		p.comments = p.comments[1:]
+	}
 	return statements
 }
 
 func (p *Parser) parseStatement() ASTNode {
 	switch p.currentToken.Type {
-	case SKIBIDI:
+	case SKIBIDI, DEADASS:
 		return p.parseVarDecl()
 	case IDENTIFIER:
 		return p.parseAssignment()
@@ -623,13 +987,26 @@ func (p *Parser) parseStatement() ASTNode {
 		return p.parseBetaCallStmt()
 	case ALPHA:
 		return p.parseAlphaReturn()
+	case IMPORT:
+		return p.parseImportStmt()
+	case VIBECHECK:
+		return p.parseTryStmt()
+	case YEET:
+		line := p.currentToken.Line
+		p.eat(YEET)
+		value := p.parseExpression()
+		p.eat(OHIO)
+		return &ThrowStmt{Value: value, Line: line}
 	default:
+		p.failAtEnd()
 		panic(fmt.Sprintf("Unexpected token %s at line %d", p.currentToken.Value, p.currentToken.Line))
 	}
 }
 
 func (p *Parser) parseVarDecl() ASTNode {
-	p.eat(SKIBIDI)
+	line := p.currentToken.Line
+	constant := p.currentToken.Type == DEADASS
+	p.eat(p.currentToken.Type)
 	name := p.currentToken.Value
 	p.eat(IDENTIFIER)
 
@@ -637,38 +1014,52 @@ func (p *Parser) parseVarDecl() ASTNode {
 	if p.currentToken.Type == RIZZ {
 		p.eat(RIZZ)
 	} else {
+		p.failAtEnd()
 		panic(fmt.Sprintf("Expected 'rizz' after variable name, got %s at line %d", p.currentToken.Value, p.currentToken.Line))
 	}
 
 	value := p.parseExpression()
 	p.eat(OHIO)
-	return &VarDecl{Name: name, Value: value}
+	return &VarDecl{Name: name, Value: value, Constant: constant, Line: line}
 }
 
 func (p *Parser) parseAssignment() ASTNode {
-	name := p.currentToken.Value
-	p.eat(IDENTIFIER)
+	line := p.currentToken.Line
+	module, name := p.parseQualifiedName()
+
+	// Built-ins such as assert can be called as statements without beta
+	if p.currentToken.Type == LPAREN {
+		args := p.parseCallArgs()
+		p.eat(OHIO)
+		return &BetaCall{Module: module, Name: name, Args: args, Line: line}
+	}
+	if module != "" {
+		panic(fmt.Sprintf("Cannot assign to %s.%s from outside its module at line %d", module, name, line))
+	}
 
 	// Handle both 'rizz' keyword and '=' symbol for assignment
 	if p.currentToken.Type == RIZZ {
 		p.eat(RIZZ)
 	} else {
+		p.failAtEnd()
 		panic(fmt.Sprintf("Expected 'rizz' after variable name, got %s at line %d", p.currentToken.Value, p.currentToken.Line))
 	}
 
 	value := p.parseExpression()
 	p.eat(OHIO)
-	return &Assignment{Name: name, Value: value}
+	return &Assignment{Name: name, Value: value, Line: line}
 }
 
 func (p *Parser) parsePrintStmt() ASTNode {
+	line := p.currentToken.Line
 	p.eat(GYATT)
 	value := p.parseExpression()
 	p.eat(OHIO)
-	return &PrintStmt{Value: value}
+	return &PrintStmt{Value: value, Line: line}
 }
 
 func (p *Parser) parseIfStmt() ASTNode {
+	line := p.currentToken.Line
 	p.eat(CAP)
 	p.eat(LPAREN)
 	condition := p.parseExpression()
@@ -681,19 +1072,45 @@ func (p *Parser) parseIfStmt() ASTNode {
 		elseBlock = p.parseBlock()
 	}
 
-	return &IfStmt{Condition: condition, ThenBlock: thenBlock, ElseBlock: elseBlock}
+	return &IfStmt{Condition: condition, ThenBlock: thenBlock, ElseBlock: elseBlock, Line: line}
+}
+
+func (p *Parser) parseTryStmt() ASTNode {
+	line := p.currentToken.Line
+	p.eat(VIBECHECK)
+	stmt := &TryStmt{Body: p.parseBlock(), Line: line}
+	if p.currentToken.Type == COPIUM {
+		p.eat(COPIUM)
+		p.eat(LPAREN)
+		stmt.CatchName = p.currentToken.Value
+		stmt.CatchLine = p.currentToken.Line
+		p.eat(IDENTIFIER)
+		p.eat(RPAREN)
+		stmt.CatchBlock = p.parseBlock()
+	}
+	if p.currentToken.Type == TOUCHGRASS {
+		p.eat(TOUCHGRASS)
+		stmt.FinallyBlock = p.parseBlock()
+	}
+	if stmt.CatchBlock == nil && stmt.FinallyBlock == nil {
+		p.failAtEnd()
+		panic(fmt.Sprintf("Expected copium or touchgrass after vibecheck block at line %d", p.currentToken.Line))
+	}
+	return stmt
 }
 
 func (p *Parser) parseWhileStmt() ASTNode {
+	line := p.currentToken.Line
 	p.eat(BUSSIN)
 	p.eat(LPAREN)
 	condition := p.parseExpression()
 	p.eat(RPAREN)
 	body := p.parseBlock()
-	return &WhileStmt{Condition: condition, Body: body}
+	return &WhileStmt{Condition: condition, Body: body, Line: line}
 }
 
 func (p *Parser) parseSigmaFunc() ASTNode {
+	line := p.currentToken.Line
 	p.eat(SIGMA)
 	name := p.currentToken.Value
 	p.eat(IDENTIFIER)
@@ -710,60 +1127,96 @@ func (p *Parser) parseSigmaFunc() ASTNode {
 	}
 	p.eat(RPAREN)
 	body := p.parseBlock()
-	return &SigmaFunc{Name: name, Params: params, Body: body}
+	return &SigmaFunc{Name: name, Params: params, Body: body, Line: line, EndLine: p.lastLine}
 }
 
 func (p *Parser) parseBetaCallStmt() ASTNode {
+	line := p.currentToken.Line
 	p.eat(BETA)
-	name := p.currentToken.Value
-	p.eat(IDENTIFIER)
-	p.eat(LPAREN)
-	args := []ASTNode{}
-	if p.currentToken.Type != RPAREN {
-		args = append(args, p.parseExpression())
-		for p.currentToken.Type == COMMA {
-			p.eat(COMMA)
-			args = append(args, p.parseExpression())
-		}
+	module, name := p.parseQualifiedName()
+	args := p.parseCallArgs()
+	p.eat(OHIO)
+	return &BetaCall{Module: module, Name: name, Args: args, Line: line, Beta: true}
+}
+
+// parseImportStmt parses import "path" ohio, or import "path" as name ohio.
+// Without as, the namespace is the file's name.
+func (p *Parser) parseImportStmt() ASTNode {
+	line := p.currentToken.Line
+	p.eat(IMPORT)
+	path := p.currentToken.Value
+	p.eat(STRING)
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if p.currentToken.Type == IDENTIFIER && p.currentToken.Value == "as" {
+		p.eat(IDENTIFIER)
+		name = p.currentToken.Value
+		p.eat(IDENTIFIER)
+	} else if !isIdentifier(name) {
+		panic(fmt.Sprintf("Module name %q is not a valid name, use import \"%s\" as name at line %d", name, path, line))
 	}
-	p.eat(RPAREN)
 	p.eat(OHIO)
-	return &BetaCall{Name: name, Args: args}
+	return &ImportStmt{Path: path, Name: name, Line: line}
+}
+
+// isIdentifier reports whether name could be written as a variable name.
+func isIdentifier(name string) bool {
+	if _, keyword := keywordDocs[name]; keyword || name == "" {
+		return false
+	}
+	if !((name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z')) {
+		return false
+	}
+	for _, r := range name {
+		if !isIdentRune(r) {
+			return false
+		}
+	}
+	return true
 }
 
 func (p *Parser) parseAlphaReturn() ASTNode {
+	line := p.currentToken.Line
 	p.eat(ALPHA)
-	value := p.parseExpression()
+	// A bare alpha returns null
+	var value ASTNode
+	if p.currentToken.Type != OHIO {
+		value = p.parseExpression()
+	}
 	p.eat(OHIO)
-	return &AlphaReturn{Value: value}
+	return &AlphaReturn{Value: value, Line: line}
 }
 
 func (p *Parser) parseVarDeclNoOhio() ASTNode {
+	line := p.currentToken.Line
 	p.eat(SKIBIDI)
 	name := p.currentToken.Value
 	p.eat(IDENTIFIER)
 	if p.currentToken.Type == RIZZ {
 		p.eat(RIZZ)
 	} else {
+		p.failAtEnd()
 		panic(fmt.Sprintf("Expected 'rizz' after variable name, got %s at line %d", p.currentToken.Value, p.currentToken.Line))
 	}
 	value := p.parseExpression()
-	return &VarDecl{Name: name, Value: value}
+	return &VarDecl{Name: name, Value: value, Line: line}
 }
 
 func (p *Parser) parseAssignmentNoOhio() ASTNode {
+	line := p.currentToken.Line
 	name := p.currentToken.Value
 	p.eat(IDENTIFIER)
 	if p.currentToken.Type == RIZZ {
 		p.eat(RIZZ)
 	} else {
+		p.failAtEnd()
 		panic(fmt.Sprintf("Expected 'rizz' after variable name, got %s at line %d", p.currentToken.Value, p.currentToken.Line))
 	}
 	value := p.parseExpression()
-	return &Assignment{Name: name, Value: value}
+	return &Assignment{Name: name, Value: value, Line: line}
 }
 
 func (p *Parser) parseForStmt() ASTNode {
+	line := p.currentToken.Line
 	p.eat(FOR)
 	p.eat(LPAREN)
 	var init ASTNode
@@ -785,69 +1238,228 @@ func (p *Parser) parseForStmt() ASTNode {
 	}
 	p.eat(RPAREN)
 	body := p.parseBlock()
-	return &ForStmt{Init: init, Condition: cond, Post: post, Body: body}
+	return &ForStmt{Init: init, Condition: cond, Post: post, Body: body, Line: line}
 }
 
 func (p *Parser) Parse() *Program {
 	statements := []ASTNode{}
 
 	for p.currentToken.Type != EOF {
+		statements = p.takeTrivia(statements)
 		stmt := p.parseStatement()
 		statements = append(statements, stmt)
 	}
+	statements = p.takeTrivia(statements)
 
 	return &Program{Statements: statements}
 }
 
 // Interpreter
 type callFrame struct {
-	variables   map[string]interface{}
-	returnValue interface{}
-	returned    bool
+	names     []string        // variable names, by the slots the resolver gave them
+	values    []interface{}   // and their values
+	constants map[string]bool // names declared with deadass
+	parent    *callFrame      // the scope around this one; the top level for a sigma
+	function  *SigmaFunc      // set on the frame pushed by a beta call
+	callLine  int             // line of that beta call
+}
+
+func (f *callFrame) slot(name string) int {
+	for idx, n := range f.names {
+		if n == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// define adds a variable in the frame's next slot.
+func (f *callFrame) define(name string, value interface{}) {
+	f.names = append(f.names, name)
+	f.values = append(f.values, value)
+}
+
+// variables maps the frame's names to their values, for tools that list
+// them.
+func (f *callFrame) variables() map[string]interface{} {
+	vars := make(map[string]interface{}, len(f.names))
+	for idx, name := range f.names {
+		vars[name] = f.values[idx]
+	}
+	return vars
 }
 
 type Interpreter struct {
-	globals      map[string]interface{}
 	functions    map[string]*SigmaFunc
 	callStack    []*callFrame
+	spareFrames  []*callFrame // block scopes that ended, for pushScope to reuse
 	inputScanner *bufio.Scanner
+	output       io.Writer
+
+	// beforeStatement is called before each statement runs, with the
+	// interpreter running it, giving the debugger a place to pause
+	beforeStatement func(i *Interpreter, stmt ASTNode)
+	// interrupt is called whenever a condition is tested, so the debugger
+	// can also stop a loop whose body runs no statements
+	interrupt func(i *Interpreter)
+	observers []ExecutionObserver
+
+	path    string             // file being run, for resolving imports
+	modules map[string]*Module // imported namespaces by name
+	loader  *moduleLoader      // shared with the interpreters of imported modules
+	caller  *Interpreter       // the interpreter running this module's code, while it does
+
+	random      *rand.Rand   // also shared with imported modules, so one seed covers them
+	permissions *Permissions // what the file and env built-ins may touch
+	scriptArgs  []string     // what args() returns
+	exitCode    int          // set when the program calls exit
+	line        int          // line of the statement running, for caught errors
+	interactive bool         // REPL: entries may declare globals again, see resolver
+
+	// returning is set by alpha until the sigma it returns from has ended,
+	// so every block between the two stops running
+	returning   bool
+	returnValue interface{}
+}
+
+// ExecutionObserver is told what a running Interpreter does. The tracer is
+// built on it; depth is the number of sigma calls in progress.
+type ExecutionObserver interface {
+	Statement(stmt ASTNode, depth int)
+	Assigned(stmt ASTNode, name string, value interface{}, depth int)
+	Condition(stmt ASTNode, result bool, depth int)
+	Enter(fn *SigmaFunc, args []interface{}, depth int)
+	Exit(fn *SigmaFunc, result interface{}, depth int)
 }
 
 func NewInterpreter() *Interpreter {
 	return &Interpreter{
-		globals:      make(map[string]interface{}),
 		functions:    make(map[string]*SigmaFunc),
-		callStack:    []*callFrame{{variables: make(map[string]interface{})}},
+		callStack:    []*callFrame{{}},
 		inputScanner: bufio.NewScanner(os.Stdin),
+		output:       os.Stdout,
+		modules:      make(map[string]*Module),
+		loader:       newModuleLoader(),
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		permissions:  &Permissions{},
 	}
 }
 
+// AssertionError is raised by assert and assert_eq so the test runner can
+// tell a failed check apart from any other runtime error.
+type AssertionError struct {
+	Message string
+	Line    int
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("%s at line %d", e.Message, e.Line)
+}
+
 func (i *Interpreter) currentFrame() *callFrame {
 	return i.callStack[len(i.callStack)-1]
 }
 
-func (i *Interpreter) getVar(name string) (interface{}, bool) {
+// callDepth counts the sigma functions currently running, including those
+// of the program running this module's code.
+func (i *Interpreter) callDepth() int {
+	depth := 0
+	if i.caller != nil {
+		depth = i.caller.callDepth()
+	}
+	for _, frame := range i.callStack {
+		if frame.function != nil {
+			depth++
+		}
+	}
+	return depth
+}
+
+// frameAt returns the frame depth scopes out from the running one.
+func (i *Interpreter) frameAt(depth int) *callFrame {
+	frame := i.currentFrame()
+	for ; depth > 0; depth-- {
+		frame = frame.parent
+	}
+	return frame
+}
+
+// lookup finds the innermost frame on the call stack that has name, for
+// names the resolver left unbound. Like variables always were before the
+// resolver, these are seen by the sigmas called from where they live.
+func (i *Interpreter) lookup(name string) (*callFrame, int) {
 	for idx := len(i.callStack) - 1; idx >= 0; idx-- {
-		if val, ok := i.callStack[idx].variables[name]; ok {
-			return val, true
+		if slot := i.callStack[idx].slot(name); slot >= 0 {
+			return i.callStack[idx], slot
 		}
 	}
-	if val, ok := i.globals[name]; ok {
-		return val, true
+	return nil, -1
+}
+
+func (i *Interpreter) getVar(name string) (interface{}, bool) {
+	if frame, slot := i.lookup(name); frame != nil {
+		return frame.values[slot], true
 	}
 	return nil, false
 }
 
-func (i *Interpreter) setVar(name string, value interface{}) {
-	for idx := len(i.callStack) - 1; idx >= 0; idx-- {
-		if _, ok := i.callStack[idx].variables[name]; ok {
-			i.callStack[idx].variables[name] = value
-			return
+func (i *Interpreter) readVar(n *Identifier) interface{} {
+	if n.Var.Bound {
+		frame := i.frameAt(n.Var.Depth)
+		if n.Var.Slot < len(frame.values) {
+			return frame.values[n.Var.Slot]
 		}
+		// A global read by a sigma called before the top level declared it
+	} else if val, exists := i.getVar(n.Name); exists {
+		return val
+	} else if val, ok := builtinConstants[n.Name]; ok {
+		return val
 	}
-	i.currentFrame().variables[name] = value
+	panic(fmt.Sprintf("Undefined variable: %s at line %d", n.Name, n.Line))
 }
 
+// setVar changes the variable a rizz statement names.
+func (i *Interpreter) setVar(s *Assignment, value interface{}) {
+	var frame *callFrame
+	slot := s.Var.Slot
+	if s.Var.Bound {
+		frame = i.frameAt(s.Var.Depth)
+	} else {
+		frame, slot = i.lookup(s.Name)
+		if frame != nil && frame.constants[s.Name] {
+			panic(fmt.Sprintf("Cannot reassign deadass variable %s at line %d", s.Name, s.Line))
+		}
+	}
+	if frame == nil || slot >= len(frame.values) {
+		panic(fmt.Sprintf("Cannot assign to undeclared variable %s (declare it with skibidi) at line %d", s.Name, s.Line))
+	}
+	frame.values[slot] = value
+}
+
+// declareVar creates a variable in the innermost scope, hiding any of the
+// same name further out. A deadass name is bound to a frozen copy of its
+// value so its lists and maps can't change either.
+func (i *Interpreter) declareVar(s *VarDecl, value interface{}) {
+	frame := i.currentFrame()
+	if s.Constant {
+		if frame.constants == nil {
+			frame.constants = make(map[string]bool)
+		}
+		frame.constants[s.Name] = true
+		value = freeze(value)
+	}
+	if s.Slot < len(frame.values) {
+		// A REPL entry declaring a global again
+		frame.values[s.Slot] = value
+		return
+	}
+	frame.define(s.Name, value)
+}
+
+// builtinArgs gives the fewest and most arguments each built-in function
+// accepts, for tools that check calls without running them.
+var builtinArgs = map[string][2]int{}
+
 func (i *Interpreter) evaluateExpression(node ASTNode) interface{} {
 	switch n := node.(type) {
 	case *NumberLiteral:
@@ -856,14 +1468,19 @@ func (i *Interpreter) evaluateExpression(node ASTNode) interface{} {
 		return n.Value
 	case *BoolLiteral:
 		return n.Value
+	case *NullLiteral:
+		return nil
 	case *Identifier:
-		if val, exists := i.getVar(n.Name); exists {
-			return val
+		if n.Module != "" {
+			return i.moduleVar(n)
 		}
-		panic(fmt.Sprintf("Undefined variable: %s", n.Name))
+		return i.readVar(n)
 	case *BinaryOp:
 		left := i.evaluateExpression(n.Left)
 		right := i.evaluateExpression(n.Right)
+		if left == nil || right == nil {
+			i.checkNullOperand(n, left, right)
+		}
 
 		switch n.Operator {
 		case "+":
@@ -889,12 +1506,20 @@ func (i *Interpreter) evaluateExpression(node ASTNode) interface{} {
 		case ">=":
 			return i.toFloat(left) >= i.toFloat(right)
 		case "%":
-			return float64(int64(i.toFloat(left)) % int64(i.toFloat(right)))
+			return modulo(i.toFloat(left), i.toFloat(right))
 		case "&&":
 			return i.toBool(left) && i.toBool(right)
 		case "||":
 			return i.toBool(left) || i.toBool(right)
 		}
+	case *ListLiteral:
+		items := make([]interface{}, len(n.Elements))
+		for idx, element := range n.Elements {
+			items[idx] = i.evaluateExpression(element)
+		}
+		return &List{Items: items}
+	case *IndexExpr:
+		return i.index(i.evaluateExpression(n.Target), i.evaluateExpression(n.Index), n.Line)
 	case *InputExpr:
 		fmt.Print("")
 		if i.inputScanner.Scan() {
@@ -902,56 +1527,77 @@ func (i *Interpreter) evaluateExpression(node ASTNode) interface{} {
 		}
 		return ""
 	case *BetaCall:
-		// Built-in functions
-		if n.Name == "len" {
-			if len(n.Args) != 1 {
-				panic("len expects 1 argument")
-			}
-			arg := i.evaluateExpression(n.Args[0])
-			switch v := arg.(type) {
-			case string:
-				return float64(len(v))
-			default:
-				panic("len expects a string argument")
-			}
-		} else if n.Name == "abs" {
-			if len(n.Args) != 1 {
-				panic("abs expects 1 argument")
-			}
-			arg := i.toFloat(i.evaluateExpression(n.Args[0]))
-			return math.Abs(arg)
-		} else if n.Name == "str" {
-			if len(n.Args) != 1 {
-				panic("str expects 1 argument")
-			}
-			arg := i.evaluateExpression(n.Args[0])
-			return i.toString(arg)
+		if n.Module != "" {
+			return i.callModuleFunction(n)
 		}
-		// User-defined function call
-		fn, ok := i.functions[n.Name]
-		if !ok {
-			panic(fmt.Sprintf("Undefined function: %s", n.Name))
+		// A sigma takes over a built-in of the same name, so programs that
+		// defined their own max or join keep working as built-ins are added
+		if fn, ok := i.functions[n.Name]; ok {
+			return i.callFunction(fn, i.evaluateArgs(fn, n), n.Line)
 		}
-		if len(fn.Params) != len(n.Args) {
-			panic(fmt.Sprintf("Function %s expects %d args, got %d", n.Name, len(fn.Params), len(n.Args)))
+		if fn, ok := builtins[n.Name]; ok {
+			return i.callBuiltin(n, fn)
 		}
-		frame := &callFrame{variables: make(map[string]interface{})}
-		for idx, param := range fn.Params {
-			frame.variables[param] = i.evaluateExpression(n.Args[idx])
-		}
-		i.callStack = append(i.callStack, frame)
-		for _, stmt := range fn.Body {
-			i.executeStatement(stmt)
-			if frame.returned {
-				break
-			}
-		}
-		i.callStack = i.callStack[:len(i.callStack)-1]
-		return frame.returnValue
+		panic(fmt.Sprintf("Undefined function: %s", n.Name))
 	}
 	panic(fmt.Sprintf("Unknown expression type: %T", node))
 }
 
+// evaluateArgs checks a call has the right number of arguments for fn and
+// evaluates them.
+func (i *Interpreter) evaluateArgs(fn *SigmaFunc, call *BetaCall) []interface{} {
+	if len(fn.Params) != len(call.Args) {
+		panic(fmt.Sprintf("Function %s expects %d args, got %d", call.Name, len(fn.Params), len(call.Args)))
+	}
+	args := make([]interface{}, len(call.Args))
+	for idx, arg := range call.Args {
+		args[idx] = i.evaluateExpression(arg)
+	}
+	return args
+}
+
+// callFunction runs a sigma with its parameters bound to args and returns
+// what it alpha'd.
+func (i *Interpreter) callFunction(fn *SigmaFunc, args []interface{}, line int) interface{} {
+	// The parameters are the first slots; the full slice expression stops
+	// locals being appended into fn.Params
+	params := len(fn.Params)
+	frame := &callFrame{names: fn.Params[:params:params], values: args, parent: i.callStack[0], function: fn, callLine: line}
+	i.callStack = append(i.callStack, frame)
+	depth := i.callDepth()
+	for _, o := range i.observers {
+		o.Enter(fn, args, depth)
+	}
+	i.runStatements(fn.Body)
+	result := i.returnValue
+	i.returning = false
+	i.returnValue = nil
+	for _, o := range i.observers {
+		o.Exit(fn, result, depth)
+	}
+	i.callStack = i.callStack[:len(i.callStack)-1]
+	return result
+}
+
+// checkNullOperand rejects null in arithmetic and ordering. Only ==, &&
+// and || take null, and + when it is joining null onto a string.
+func (i *Interpreter) checkNullOperand(n *BinaryOp, left, right interface{}) {
+	switch n.Operator {
+	case "==", "&&", "||":
+		return
+	case "+":
+		_, leftStr := left.(string)
+		_, rightStr := right.(string)
+		if leftStr || rightStr {
+			return
+		}
+	}
+	if isNegation(n) {
+		panic(fmt.Sprintf("Cannot negate null at line %d", nodeLine(n)))
+	}
+	panic(fmt.Sprintf("Cannot use null with %s at line %d", n.Operator, nodeLine(n)))
+}
+
 func (i *Interpreter) add(left, right interface{}) interface{} {
 	if leftStr, ok := left.(string); ok {
 		return leftStr + i.toString(right)
@@ -963,6 +1609,37 @@ func (i *Interpreter) add(left, right interface{}) interface{} {
 }
 
 func (i *Interpreter) equals(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if _, ok := left.(*ErrorValue); ok {
+		return left == right
+	}
+	if leftList, ok := left.(*List); ok {
+		rightList, ok := right.(*List)
+		if !ok || len(leftList.Items) != len(rightList.Items) {
+			return false
+		}
+		for idx := range leftList.Items {
+			if !i.equals(leftList.Items[idx], rightList.Items[idx]) {
+				return false
+			}
+		}
+		return true
+	}
+	if leftMap, ok := left.(*Map); ok {
+		rightMap, ok := right.(*Map)
+		if !ok || len(leftMap.Keys) != len(rightMap.Keys) {
+			return false
+		}
+		for key, value := range leftMap.Values {
+			other, ok := rightMap.Values[key]
+			if !ok || !i.equals(value, other) {
+				return false
+			}
+		}
+		return true
+	}
 	if leftStr, ok := left.(string); ok {
 		if rightStr, ok := right.(string); ok {
 			return leftStr == rightStr
@@ -986,12 +1663,16 @@ func (i *Interpreter) toFloat(val interface{}) float64 {
 			return 1
 		}
 		return 0
+	case nil:
+		panic(fmt.Sprintf("Cannot use null as a number at line %d", i.line))
 	}
 	return 0
 }
 
 func (i *Interpreter) toString(val interface{}) string {
 	switch v := val.(type) {
+	case nil:
+		return "null"
 	case string:
 		return v
 	case float64:
@@ -1004,10 +1685,131 @@ func (i *Interpreter) toString(val interface{}) string {
 			return "true"
 		}
 		return "false"
+	case *List:
+		items := make([]string, len(v.Items))
+		for idx, item := range v.Items {
+			if s, ok := item.(string); ok {
+				items[idx] = quoteString(s)
+			} else {
+				items[idx] = i.toString(item)
+			}
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *Map:
+		items := make([]string, len(v.Keys))
+		for idx, key := range v.Keys {
+			value := v.Values[key]
+			if s, ok := value.(string); ok {
+				value = quoteString(s)
+			}
+			items[idx] = quoteString(key) + ": " + i.toString(value)
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case *ErrorValue:
+		return v.Error()
 	}
 	return fmt.Sprintf("%v", val)
 }
 
+// List is a list value. Lists are shared, not copied, when assigned or
+// passed to a sigma.
+type List struct {
+	Items  []interface{}
+	Frozen bool // set on the copies made by freeze and deadass
+}
+
+// Map is a map value with string keys. It remembers the order keys were
+// added in, so printing and json_stringify are predictable.
+type Map struct {
+	Keys   []string
+	Values map[string]interface{}
+	Frozen bool
+}
+
+func NewMap() *Map {
+	return &Map{Values: make(map[string]interface{})}
+}
+
+// Set adds or replaces a key, keeping its first position.
+func (m *Map) Set(key string, value interface{}) {
+	if _, ok := m.Values[key]; !ok {
+		m.Keys = append(m.Keys, key)
+	}
+	m.Values[key] = value
+}
+
+// index returns target[index] for a string, counting characters rather
+// than bytes, a list, a map, or an error's message, kind or line.
+func (i *Interpreter) index(target, index interface{}, line int) interface{} {
+	if e, ok := target.(*ErrorValue); ok {
+		switch index {
+		case "message":
+			return e.Message
+		case "kind":
+			return e.Kind
+		case "line":
+			return float64(e.Line)
+		}
+		panic(fmt.Sprintf("Errors have message, kind and line, not %s at line %d", i.toString(index), line))
+	}
+	if m, ok := target.(*Map); ok {
+		key, ok := index.(string)
+		if !ok {
+			panic(fmt.Sprintf("Map keys are strings, got %s at line %d", typeName(index), line))
+		}
+		value, ok := m.Values[key]
+		if !ok {
+			panic(fmt.Sprintf("Key %s not found in map at line %d", quoteString(key), line))
+		}
+		return value
+	}
+	idx, ok := index.(float64)
+	if !ok || idx != math.Trunc(idx) {
+		panic(fmt.Sprintf("Index must be a whole number, got %s at line %d", i.toString(index), line))
+	}
+	switch v := target.(type) {
+	case string:
+		runes := []rune(v)
+		if idx < 0 || int(idx) >= len(runes) {
+			panic(fmt.Sprintf("Index %d out of range for string of length %d at line %d", int(idx), len(runes), line))
+		}
+		return string(runes[int(idx)])
+	case *List:
+		if idx < 0 || int(idx) >= len(v.Items) {
+			panic(fmt.Sprintf("Index %d out of range for list of length %d at line %d", int(idx), len(v.Items), line))
+		}
+		return v.Items[int(idx)]
+	}
+	panic(fmt.Sprintf("Cannot index a %s at line %d", typeName(target), line))
+}
+
+// typeName names the type of a value the way scripts see it.
+func typeName(val interface{}) string {
+	switch val.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *List:
+		return "list"
+	case *Map:
+		return "map"
+	case *ErrorValue:
+		return "error"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+func init() {
+	registerBuiltin("is_null", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return args[0] == nil
+	})
+}
+
 func (i *Interpreter) toBool(val interface{}) bool {
 	switch v := val.(type) {
 	case bool:
@@ -1016,103 +1818,171 @@ func (i *Interpreter) toBool(val interface{}) bool {
 		return v != 0
 	case string:
 		return v != ""
+	case *List:
+		return len(v.Items) > 0
+	case *Map:
+		return len(v.Keys) > 0
 	}
 	return false
 }
 
 func (i *Interpreter) executeStatement(stmt ASTNode) {
+	i.line = nodeLine(stmt)
+	if i.beforeStatement != nil {
+		i.beforeStatement(i, stmt)
+	}
+	if len(i.observers) > 0 {
+		depth := i.callDepth()
+		for _, o := range i.observers {
+			o.Statement(stmt, depth)
+		}
+	}
+	i.runStatement(stmt)
+}
+
+// condition evaluates the condition of a cap, bussin or gyatfor and tells
+// any observers which way it went.
+func (i *Interpreter) condition(stmt ASTNode, cond ASTNode) bool {
+	if i.interrupt != nil {
+		i.interrupt(i)
+	}
+	result := i.toBool(i.evaluateExpression(cond))
+	if len(i.observers) > 0 {
+		depth := i.callDepth()
+		for _, o := range i.observers {
+			o.Condition(stmt, result, depth)
+		}
+	}
+	return result
+}
+
+// assign sets a variable for a skibidi or rizz statement.
+func (i *Interpreter) assign(stmt ASTNode, name string, value interface{}) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		i.declareVar(s, value)
+	case *Assignment:
+		i.setVar(s, value)
+	}
+	if len(i.observers) > 0 {
+		depth := i.callDepth()
+		for _, o := range i.observers {
+			o.Assigned(stmt, name, value, depth)
+		}
+	}
+}
+
+// runStatement executes stmt without calling beforeStatement, so the parts
+// of a gyatfor header don't count as separate stops.
+func (i *Interpreter) runStatement(stmt ASTNode) {
 	switch s := stmt.(type) {
 	case *VarDecl:
 		value := i.evaluateExpression(s.Value)
-		i.setVar(s.Name, value)
+		i.assign(s, s.Name, value)
 	case *Assignment:
 		value := i.evaluateExpression(s.Value)
-		i.setVar(s.Name, value)
+		i.assign(s, s.Name, value)
 	case *PrintStmt:
 		value := i.evaluateExpression(s.Value)
-		fmt.Println(i.toString(value))
+		fmt.Fprintln(i.output, i.toString(value))
 	case *IfStmt:
-		condition := i.evaluateExpression(s.Condition)
-		if i.toBool(condition) {
-			i.pushScope()
-			for _, stmt := range s.ThenBlock {
-				i.executeStatement(stmt)
-				if i.currentFrame().returned {
-					break
-				}
-			}
-			i.popScope()
+		if i.condition(s, s.Condition) {
+			i.runBlock(s.ThenBlock)
 		} else if s.ElseBlock != nil {
-			i.pushScope()
-			for _, stmt := range s.ElseBlock {
-				i.executeStatement(stmt)
-				if i.currentFrame().returned {
-					break
-				}
-			}
-			i.popScope()
+			i.runBlock(s.ElseBlock)
 		}
 	case *WhileStmt:
-		for i.toBool(i.evaluateExpression(s.Condition)) {
-			i.pushScope()
-			for _, stmt := range s.Body {
-				i.executeStatement(stmt)
-				if i.currentFrame().returned {
-					break
-				}
-			}
-			i.popScope()
-			if i.currentFrame().returned {
+		for i.condition(s, s.Condition) {
+			i.runBlock(s.Body)
+			if i.returning {
 				break
 			}
 		}
 	case *ForStmt:
 		i.pushScope()
 		if s.Init != nil {
-			i.executeStatement(s.Init)
-		}
-		for i.toBool(i.evaluateExpression(s.Condition)) {
-			i.pushScope()
-			for _, stmt := range s.Body {
-				i.executeStatement(stmt)
-				if i.currentFrame().returned {
-					break
-				}
-			}
-			i.popScope()
-			if i.currentFrame().returned {
+			i.runStatement(s.Init)
+		}
+		for i.condition(s, s.Condition) {
+			i.runBlock(s.Body)
+			if i.returning {
 				break
 			}
 			if s.Post != nil {
-				i.executeStatement(s.Post)
+				i.runStatement(s.Post)
 			}
 		}
 		i.popScope()
 	case *SigmaFunc:
 		i.functions[s.Name] = s
+	case *ImportStmt:
+		i.importModule(s)
+	case *TryStmt:
+		i.runTry(s)
+	case *ThrowStmt:
+		panic(i.throwValue(i.evaluateExpression(s.Value), s.Line))
 	case *BetaCall:
 		i.evaluateExpression(s)
 	case *AlphaReturn:
-		val := i.evaluateExpression(s.Value)
-		i.currentFrame().returnValue = val
-		i.currentFrame().returned = true
+		var val interface{}
+		if s.Value != nil {
+			val = i.evaluateExpression(s.Value)
+		}
+		i.returnValue = val
+		i.returning = true
+	}
+}
+
+// runBlock runs statements in a scope of their own.
+func (i *Interpreter) runBlock(statements []ASTNode) {
+	i.pushScope()
+	i.runStatements(statements)
+	i.popScope()
+}
+
+// runStatements runs statements until one of them returns.
+func (i *Interpreter) runStatements(statements []ASTNode) {
+	for _, stmt := range statements {
+		i.executeStatement(stmt)
+		if i.returning {
+			break
+		}
 	}
 }
 
+// pushScope starts a block scope, reusing one that ended if it can, so a
+// loop body doesn't allocate a frame and its slots on every pass.
 func (i *Interpreter) pushScope() {
-	i.callStack = append(i.callStack, &callFrame{variables: make(map[string]interface{})})
+	parent := i.currentFrame()
+	if n := len(i.spareFrames); n > 0 {
+		frame := i.spareFrames[n-1]
+		i.spareFrames = i.spareFrames[:n-1]
+		frame.parent = parent
+		i.callStack = append(i.callStack, frame)
+		return
+	}
+	i.callStack = append(i.callStack, &callFrame{parent: parent})
 }
 
+// popScope ends the block scope pushScope started. Nothing refers to a
+// block's frame once it has ended, so it is kept for the next block.
 func (i *Interpreter) popScope() {
 	if len(i.callStack) > 1 {
+		frame := i.currentFrame()
 		i.callStack = i.callStack[:len(i.callStack)-1]
+		clear(frame.values)
+		*frame = callFrame{names: frame.names[:0], values: frame.values[:0]}
+		i.spareFrames = append(i.spareFrames, frame)
 	}
 }
 
+// Execute resolves a program's names and runs its top level. An alpha
+// outside any sigma ends it.
 func (i *Interpreter) Execute(program *Program) {
-	for _, stmt := range program.Statements {
-		i.executeStatement(stmt)
-	}
+	i.resolve(program)
+	i.runStatements(program.Statements)
+	i.returning = false
+	i.returnValue = nil
 }
 
 // Main function
@@ -1122,6 +1992,18 @@ func main() {
 		fmt.Println("Usage:")
 		fmt.Println("  ./skibidi run <filename.skibidi>  - Run a Skibidi program (Linux/Mac)")
 		fmt.Println("  .\\skibidi.exe run <filename.skibidi>  - Run a Skibidi program (Windows)")
+		fmt.Println("  ./skibidi run --trace <file>      - Run and log every step to stderr")
+		fmt.Println("  ./skibidi run --profile out.prof <file> - Profile time per sigma and line")
+		fmt.Println("  ./skibidi run --coverage <file>   - Report which lines and branches ran")
+		fmt.Println("  ./skibidi run --seed 42 <file>    - Run with repeatable random numbers")
+		fmt.Println("  ./skibidi run --allow-read=. <file> - Let a program read files (also --allow-write)")
+		fmt.Println("  ./skibidi run <file> a b c        - Pass arguments, read with args()")
+		fmt.Println("  ./skibidi test [dir]              - Run sigma test_* functions")
+		fmt.Println("  ./skibidi debug <file>            - Step through a program")
+		fmt.Println("  ./skibidi fmt [-w] <file>         - Format a Skibidi program")
+		fmt.Println("  ./skibidi lint [-json] <file>     - Check a program for likely bugs")
+		fmt.Println("  ./skibidi lsp                     - Start the language server on stdio")
+		fmt.Println("  ./skibidi dap                     - Start the debug adapter on stdio")
 		fmt.Println("  ./skibidi -i                      - Interactive mode (Linux/Mac)")
 		fmt.Println("  .\\skibidi.exe -i                  - Interactive mode (Windows)")
 		fmt.Println("  ./skibidi help                    - Show this help")
@@ -1141,13 +2023,14 @@ func main() {
 
 	switch command {
 	case "run":
-		if len(os.Args) < 3 {
-			fmt.Println("❌ Error: Please specify a file to run")
-			fmt.Println("Usage: skibidi run <filename.skibidi>")
+		opts, err := parseRunArgs(os.Args[2:])
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			fmt.Println("Usage: skibidi run [--trace] [--trace-out <file>] [--profile <file>] [--coverage] [--seed N] [--allow-read=<dir>] [--allow-write=<dir>] [--allow-env] <filename.skibidi> [args...]")
 			return
 		}
 
-		filename := os.Args[2]
+		filename := opts.filename
 		if !strings.HasSuffix(filename, ".skibidi") {
 			fmt.Printf("⚠️  Warning: File '%s' doesn't have .skibidi extension\n", filename)
 		}
@@ -1158,23 +2041,170 @@ func main() {
 			return
 		}
 
+		interpreter := NewInterpreter()
+		interpreter.SetFile(filename)
+		if opts.seeded {
+			interpreter.random.Seed(opts.seed)
+		}
+		for _, dir := range opts.allowRead {
+			if err := interpreter.permissions.AllowRead(dir); err != nil {
+				fmt.Printf("❌ Error: --allow-read %s: %v\n", dir, err)
+				return
+			}
+		}
+		for _, dir := range opts.allowWrite {
+			if err := interpreter.permissions.AllowWrite(dir); err != nil {
+				fmt.Printf("❌ Error: --allow-write %s: %v\n", dir, err)
+				return
+			}
+		}
+		for _, names := range opts.allowEnv {
+			interpreter.permissions.AllowEnv(names)
+		}
+		interpreter.scriptArgs = opts.args
+		if opts.trace {
+			var traceOut io.Writer = os.Stderr
+			if opts.traceFile != "" {
+				file, err := os.Create(opts.traceFile)
+				if err != nil {
+					fmt.Printf("❌ Error creating trace file '%s': %v\n", opts.traceFile, err)
+					return
+				}
+				defer file.Close()
+				traceOut = file
+			}
+			interpreter.observers = append(interpreter.observers, NewTracer(traceOut, interpreter))
+		}
+		var profiler *Profiler
+		if opts.profile != "" {
+			profiler = NewProfiler(filename, string(content))
+			interpreter.observers = append(interpreter.observers, profiler)
+		}
+		var coverage *Coverage
+		if opts.coverage != "" {
+			coverage = NewCoverage(filename, string(content))
+			interpreter.observers = append(interpreter.observers, coverage)
+		}
+
 		fmt.Printf("🚀 Running Skibidi program: %s\n", filename)
 		fmt.Println("" + strings.Repeat("=", 40))
-		runSkibidi(string(content))
+		runSkibidiInterpreter(string(content), interpreter)
 		fmt.Println("" + strings.Repeat("=", 40))
 		fmt.Println("✅ Program execution completed!")
 
+		if profiler != nil {
+			profiler.Finish()
+			profiler.PrintSummary(os.Stderr, opts.profileTop)
+			if err := profiler.writeProfile(opts.profile); err != nil {
+				fmt.Printf("❌ Error writing profile '%s': %v\n", opts.profile, err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "📝 Profile written to %s (pprof) and %s.folded (flamegraph)\n", opts.profile, opts.profile)
+		}
+		if coverage != nil {
+			coverage.PrintSummary(os.Stderr)
+			if err := coverage.writeCoverage(opts.coverage); err != nil {
+				fmt.Printf("❌ Error writing coverage to '%s': %v\n", opts.coverage, err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "📝 Coverage written to %s\n", opts.coverage)
+		}
+		if interpreter.exitCode != 0 {
+			os.Exit(interpreter.exitCode)
+		}
+
+	case "test":
+		path := "."
+		verbose := false
+		for _, arg := range os.Args[2:] {
+			if arg == "-v" {
+				verbose = true
+			} else {
+				path = arg
+			}
+		}
+		if !runTests(path, verbose) {
+			os.Exit(1)
+		}
+
+	case "debug":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ Error: Please specify a file to debug")
+			fmt.Println("Usage: skibidi debug <filename.skibidi>")
+			return
+		}
+		filename := os.Args[2]
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Printf("❌ Error reading file '%s': %v\n", filename, err)
+			return
+		}
+		runDebug(filename, string(content))
+
+	case "fmt":
+		write := false
+		var files []string
+		for _, arg := range os.Args[2:] {
+			if arg == "-w" {
+				write = true
+			} else {
+				files = append(files, arg)
+			}
+		}
+		if len(files) == 0 {
+			fmt.Println("❌ Error: Please specify a file to format")
+			fmt.Println("Usage: skibidi fmt [-w] <filename.skibidi>...")
+			return
+		}
+		if !runFmt(files, write) {
+			os.Exit(1)
+		}
+
+	case "lint":
+		asJSON := false
+		var files []string
+		for _, arg := range os.Args[2:] {
+			if arg == "-json" {
+				asJSON = true
+			} else {
+				files = append(files, arg)
+			}
+		}
+		if len(files) == 0 {
+			fmt.Println("❌ Error: Please specify a file to lint")
+			fmt.Println("Usage: skibidi lint [-json] <filename.skibidi>...")
+			return
+		}
+		if !runLint(files, asJSON) {
+			os.Exit(1)
+		}
+
+	case "lsp":
+		runLSP()
+
+	case "dap":
+		runDAP()
+
 	case "-i", "interactive":
 		runInteractive()
 
 	case "help", "-h", "--help":
 		fmt.Println("🚽 Skibidi Programming Language v1.0")
 		fmt.Println("\n📚 Commands:")
-		fmt.Println("  skibidi run <file>    - Run a Skibidi program")
+		fmt.Println("  skibidi run <file>    - Run a Skibidi program (--trace logs each step,")
+		fmt.Println("                          --profile <out> times each sigma and line,")
+		fmt.Println("                          --coverage reports what ran)")
+		fmt.Println("  skibidi test [dir]   - Run sigma test_* functions (-v shows output)")
+		fmt.Println("  skibidi debug <file> - Debug with breakpoints and stepping")
+		fmt.Println("  skibidi fmt <file>   - Print formatted source (-w rewrites the file)")
+		fmt.Println("  skibidi lint <file>  - Report likely bugs (-json for machine output)")
+		fmt.Println("  skibidi lsp          - Start the language server (for editors)")
+		fmt.Println("  skibidi dap          - Start the debug adapter (for editors)")
 		fmt.Println("  skibidi -i           - Start interactive mode")
 		fmt.Println("  skibidi help         - Show this help")
 		fmt.Println("\n🔧 Example Usage:")
 		fmt.Println("  skibidi run hello.skibidi")
+		fmt.Println("  skibidi test test/")
 		fmt.Println("  skibidi -i")
 
 	default:
@@ -1183,113 +2213,111 @@ func main() {
 	}
 }
 
-func runInteractive() {
-	fmt.Println("🚽 Skibidi Interactive Mode v2.0 🚽")
-	fmt.Println("Type :help for commands. Type 'exit' or :exit to quit.")
-	scanner := bufio.NewScanner(os.Stdin)
-	interpreter := NewInterpreter()
-
-	var inputLines []string
-	var openBraces int
-
-	for {
-		prompt := "skibidi> "
-		if openBraces > 0 {
-			prompt = "... "
-		}
-		fmt.Print(prompt)
-		if !scanner.Scan() {
-			fmt.Println("Goodbye! Stay sigma! 🗿")
-			break
-		}
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		if openBraces == 0 && strings.HasPrefix(trimmed, ":") {
-			replCmd := strings.ToLower(strings.TrimSpace(trimmed[1:]))
-			switch replCmd {
-			case "exit":
-				fmt.Println("Goodbye! Stay sigma! 🗿")
-				return
-			case "help":
-				fmt.Println("Available commands: :help, :vars, :funcs, :exit")
-				continue
-			case "vars":
-				fmt.Println("Variables:")
-				for k, v := range interpreter.currentFrame().variables {
-					fmt.Printf("  %s = %v\n", k, v)
-				}
-				continue
-			case "funcs":
-				fmt.Println("Functions:")
-				for k := range interpreter.functions {
-					fmt.Printf("  %s\n", k)
+// runOptions holds the flags given to skibidi run.
+type runOptions struct {
+	filename   string
+	trace      bool
+	traceFile  string
+	profile    string
+	profileTop int
+	coverage   string // directory for coverage reports
+	seed       int64
+	seeded     bool
+	allowRead  []string
+	allowWrite []string
+	allowEnv   []string
+	args       []string // everything after the filename, for the program
+}
+
+// parseRunArgs reads the flags before the program's filename. Anything
+// after the filename is left for the program to read with args().
+func parseRunArgs(args []string) (runOptions, error) {
+	opts := runOptions{profileTop: 10}
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		switch {
+		case arg == "--trace":
+			opts.trace = true
+		case arg == "--trace-out":
+			if idx+1 >= len(args) {
+				return opts, fmt.Errorf("--trace-out needs a file name")
+			}
+			idx++
+			opts.trace = true
+			opts.traceFile = args[idx]
+		case strings.HasPrefix(arg, "--trace-out="):
+			opts.trace = true
+			opts.traceFile = strings.TrimPrefix(arg, "--trace-out=")
+		case arg == "--profile":
+			if idx+1 >= len(args) {
+				return opts, fmt.Errorf("--profile needs a file name")
+			}
+			idx++
+			opts.profile = args[idx]
+		case strings.HasPrefix(arg, "--profile="):
+			opts.profile = strings.TrimPrefix(arg, "--profile=")
+		case arg == "--profile-top":
+			if idx+1 >= len(args) {
+				return opts, fmt.Errorf("--profile-top needs a number")
+			}
+			idx++
+			top, err := strconv.Atoi(args[idx])
+			if err != nil || top < 1 {
+				return opts, fmt.Errorf("invalid --profile-top %q", args[idx])
+			}
+			opts.profileTop = top
+		case arg == "--coverage":
+			opts.coverage = "coverage"
+		case arg == "--coverage-dir":
+			if idx+1 >= len(args) {
+				return opts, fmt.Errorf("--coverage-dir needs a directory")
+			}
+			idx++
+			opts.coverage = args[idx]
+		case strings.HasPrefix(arg, "--coverage-dir="):
+			opts.coverage = strings.TrimPrefix(arg, "--coverage-dir=")
+		case arg == "--seed" || strings.HasPrefix(arg, "--seed="):
+			value := strings.TrimPrefix(arg, "--seed=")
+			if arg == "--seed" {
+				if idx+1 >= len(args) {
+					return opts, fmt.Errorf("--seed needs a number")
 				}
-				continue
-			default:
-				fmt.Println("Unknown command. Type :help for help.")
-				continue
+				idx++
+				value = args[idx]
 			}
+			seed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return opts, fmt.Errorf("invalid --seed %q", value)
+			}
+			opts.seed = seed
+			opts.seeded = true
+		case arg == "--allow-read" || arg == "--allow-write":
+			if idx+1 >= len(args) {
+				return opts, fmt.Errorf("%s needs a directory", arg)
+			}
+			idx++
+			if arg == "--allow-read" {
+				opts.allowRead = append(opts.allowRead, args[idx])
+			} else {
+				opts.allowWrite = append(opts.allowWrite, args[idx])
+			}
+		case strings.HasPrefix(arg, "--allow-read="):
+			opts.allowRead = append(opts.allowRead, strings.TrimPrefix(arg, "--allow-read="))
+		case strings.HasPrefix(arg, "--allow-write="):
+			opts.allowWrite = append(opts.allowWrite, strings.TrimPrefix(arg, "--allow-write="))
+		case arg == "--allow-env":
+			opts.allowEnv = append(opts.allowEnv, "")
+		case strings.HasPrefix(arg, "--allow-env="):
+			opts.allowEnv = append(opts.allowEnv, strings.TrimPrefix(arg, "--allow-env="))
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %s", arg)
+		default:
+			opts.filename = arg
+			opts.args = args[idx+1:]
+			return opts, nil
 		}
-
-		if trimmed == "exit" {
-			fmt.Println("Goodbye! Stay sigma! 🗿")
-			break
-		}
-		if trimmed == "" && openBraces == 0 {
-			continue
-		}
-
-		openBraces += strings.Count(line, "{")
-		openBraces -= strings.Count(line, "}")
-		inputLines = append(inputLines, line)
-
-		if openBraces > 0 {
-			continue
-		}
-
-		input := strings.Join(inputLines, "\n")
-		inputLines = nil
-		openBraces = 0
-
-		trimmedInput := strings.TrimSpace(input)
-		if !strings.HasSuffix(trimmedInput, "ohio") && !strings.Contains(trimmedInput, "{") {
-			input += " ohio"
-		}
-
-		// Try to parse as expression first, then as statement
-		triedExpr := false
-		var exprResult interface{}
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					triedExpr = false
-				} else {
-					triedExpr = true
-				}
-			}()
-			lexer := NewLexer(input)
-			parser := NewParser(lexer)
-			exprResult = interpreter.evaluateExpression(parser.parseExpression())
-			triedExpr = true
-		}()
-		if triedExpr {
-			fmt.Println(interpreter.toString(exprResult))
-			continue
-		}
-		// If not an expression, try as statement
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Printf("Skibidi Error: %v\n", r)
-				}
-			}()
-			lexer2 := NewLexer(input)
-			parser2 := NewParser(lexer2)
-			program := parser2.Parse()
-			interpreter.Execute(program)
-		}()
 	}
+	return opts, fmt.Errorf("please specify a file to run")
 }
 
 func runSkibidi(code string) {
@@ -1300,6 +2328,11 @@ func runSkibidi(code string) {
 func runSkibidiInterpreter(code string, interpreter *Interpreter) {
 	defer func() {
 		if r := recover(); r != nil {
+			if exit, ok := r.(*ExitError); ok {
+				interpreter.callStack = interpreter.callStack[:1]
+				interpreter.exitCode = exit.Code
+				return
+			}
 			fmt.Printf("Skibidi Error: %v\n", r)
 		}
 	}()
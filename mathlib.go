@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// builtinConstants are names every program can read without declaring
+// them. A variable of the same name hides the constant.
+var builtinConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// modulo is % for numbers of any sign: the result takes the sign of the
+// divisor, so -7 % 3 is 2, as counting round a clock would give.
+func modulo(left, right float64) float64 {
+	if right == 0 {
+		panic("Modulo by zero")
+	}
+	r := math.Mod(left, right)
+	if r != 0 && (r < 0) != (right < 0) {
+		r += right
+	}
+	return r
+}
+
+func gcd(a, b int64) int64 {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// numbersArg returns the numbers min and max work over: their arguments,
+// or the items of a single list argument.
+func numbersArg(name string, args []interface{}, line int) []float64 {
+	if len(args) == 1 {
+		if list, ok := args[0].(*List); ok {
+			if len(list.Items) == 0 {
+				panic(fmt.Sprintf("%s of an empty list at line %d", name, line))
+			}
+			args = list.Items
+		}
+	}
+	nums := make([]float64, len(args))
+	for idx, arg := range args {
+		n, ok := arg.(float64)
+		if !ok {
+			panic(fmt.Sprintf("%s expects numbers, got a %s at line %d", name, typeName(arg), line))
+		}
+		nums[idx] = n
+	}
+	return nums
+}
+
+// registerUnary adds a one-argument function of a number.
+func registerUnary(name string, fn func(float64) float64) {
+	registerBuiltin(name, 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return fn(numberArg(name, args, 0))
+	})
+}
+
+func init() {
+	registerUnary("floor", math.Floor)
+	registerUnary("ceil", math.Ceil)
+	registerUnary("round", math.Round)
+	registerUnary("sin", math.Sin)
+	registerUnary("cos", math.Cos)
+	registerUnary("tan", math.Tan)
+	registerUnary("exp", math.Exp)
+
+	registerBuiltin("sqrt", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		x := numberArg(name, args, 0)
+		if x < 0 {
+			panic(fmt.Sprintf("sqrt of negative number %s at line %d", i.toString(x), line))
+		}
+		return math.Sqrt(x)
+	})
+	registerBuiltin("log", 1, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		x := numberArg(name, args, 0)
+		if x <= 0 {
+			panic(fmt.Sprintf("log of non-positive number %s at line %d", i.toString(x), line))
+		}
+		if len(args) == 1 {
+			return math.Log(x)
+		}
+		base := numberArg(name, args, 1)
+		switch {
+		case base <= 0 || base == 1:
+			panic(fmt.Sprintf("log base must be positive and not 1 at line %d", line))
+		case base == 10:
+			return math.Log10(x)
+		case base == 2:
+			return math.Log2(x)
+		}
+		return math.Log(x) / math.Log(base)
+	})
+	registerBuiltin("pow", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		result := math.Pow(numberArg(name, args, 0), numberArg(name, args, 1))
+		if math.IsNaN(result) {
+			panic(fmt.Sprintf("pow result is not a real number at line %d", line))
+		}
+		return result
+	})
+	registerBuiltin("min", 1, -1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		nums := numbersArg(name, args, line)
+		result := nums[0]
+		for _, n := range nums[1:] {
+			result = math.Min(result, n)
+		}
+		return result
+	})
+	registerBuiltin("max", 1, -1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		nums := numbersArg(name, args, line)
+		result := nums[0]
+		for _, n := range nums[1:] {
+			result = math.Max(result, n)
+		}
+		return result
+	})
+	registerBuiltin("clamp", 3, 3, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		x, lo, hi := numberArg(name, args, 0), numberArg(name, args, 1), numberArg(name, args, 2)
+		if lo > hi {
+			panic(fmt.Sprintf("clamp range %s to %s is empty at line %d", i.toString(lo), i.toString(hi), line))
+		}
+		return math.Max(lo, math.Min(x, hi))
+	})
+	registerBuiltin("gcd", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return float64(gcd(int64(intArg(name, args, 0)), int64(intArg(name, args, 1))))
+	})
+	registerBuiltin("lcm", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		a, b := int64(intArg(name, args, 0)), int64(intArg(name, args, 1))
+		if a == 0 || b == 0 {
+			return float64(0)
+		}
+		result := a / gcd(a, b) * b
+		if result < 0 {
+			result = -result
+		}
+		return float64(result)
+	})
+}
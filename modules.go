@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Module is an imported file. Its top-level statements run once, in an
+// interpreter of its own, and its variables and sigmas stay there.
+type Module struct {
+	Name        string
+	Path        string
+	interpreter *Interpreter
+}
+
+// moduleLoader makes sure each file runs once however many times it is
+// imported, and spots files that end up importing themselves.
+type moduleLoader struct {
+	loaded  map[string]*Interpreter // by absolute path
+	running []string                // files whose top level is running, outermost first
+}
+
+func newModuleLoader() *moduleLoader {
+	return &moduleLoader{loaded: make(map[string]*Interpreter)}
+}
+
+// SetFile tells the interpreter which file it is running, so imports are
+// found next to it and an import of the file itself is caught as a cycle.
+func (i *Interpreter) SetFile(filename string) {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	i.path = filename
+	i.loader.running = append(i.loader.running, filename)
+}
+
+// resolveImport finds the file an import names: next to the importing
+// file first, then in each directory on SKIBIDI_PATH.
+func (i *Interpreter) resolveImport(path string, line int) string {
+	if filepath.Ext(path) == "" {
+		path += ".skibidi"
+	}
+	var candidates []string
+	if filepath.IsAbs(path) {
+		candidates = []string{path}
+	} else {
+		dir := "."
+		if i.path != "" {
+			dir = filepath.Dir(i.path)
+		}
+		candidates = append(candidates, filepath.Join(dir, path))
+		for _, dir := range filepath.SplitList(os.Getenv("SKIBIDI_PATH")) {
+			if dir != "" {
+				candidates = append(candidates, filepath.Join(dir, path))
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs
+			}
+			return candidate
+		}
+	}
+	panic(fmt.Sprintf("Cannot find module %q (looked in %s) at line %d", path, strings.Join(candidates, ", "), line))
+}
+
+func (i *Interpreter) importModule(s *ImportStmt) {
+	path := i.resolveImport(s.Path, s.Line)
+	for idx, running := range i.loader.running {
+		if running == path {
+			chain := make([]string, 0, len(i.loader.running)-idx+1)
+			for _, file := range i.loader.running[idx:] {
+				chain = append(chain, filepath.Base(file))
+			}
+			chain = append(chain, filepath.Base(path))
+			panic(fmt.Sprintf("Import cycle: %s at line %d", strings.Join(chain, " -> "), s.Line))
+		}
+	}
+
+	module, ok := i.loader.loaded[path]
+	if !ok {
+		module = i.loadModule(path, s.Line)
+	}
+	i.modules[s.Name] = &Module{Name: s.Name, Path: path, interpreter: module}
+}
+
+// moduleObserver is an ExecutionObserver that also watches the modules a
+// program imports. forModule returns the observer to give the module's
+// interpreter, which can tell the module's lines from the program's.
+type moduleObserver interface {
+	forModule(module *Interpreter, path string, code string) ExecutionObserver
+}
+
+// loadModule runs a file's top level in a fresh interpreter that shares
+// this one's input, output, loader, debugger and observers.
+func (i *Interpreter) loadModule(path string, line int) *Interpreter {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		panic(fmt.Sprintf("Cannot read module %s: %v at line %d", path, err, line))
+	}
+	program, err := parseProgram(string(content))
+	if err != nil {
+		panic(fmt.Sprintf("%v in %s", err, filepath.Base(path)))
+	}
+
+	module := NewInterpreter()
+	module.inputScanner = i.inputScanner
+	module.output = i.output
+	module.loader = i.loader
+	module.random = i.random
+	module.permissions = i.permissions
+	module.scriptArgs = i.scriptArgs
+	module.path = path
+	module.beforeStatement = i.beforeStatement
+	module.interrupt = i.interrupt
+	for _, o := range i.observers {
+		if m, ok := o.(moduleObserver); ok {
+			module.observers = append(module.observers, m.forModule(module, path, string(content)))
+		}
+	}
+
+	module.caller = i
+	i.loader.running = append(i.loader.running, path)
+	defer func() {
+		module.caller = nil
+		i.loader.running = i.loader.running[:len(i.loader.running)-1]
+		if r := recover(); r != nil {
+			if msg, ok := r.(string); ok && !strings.HasPrefix(msg, "Import cycle") {
+				r = fmt.Sprintf("%s in %s", msg, filepath.Base(path))
+			}
+			panic(r)
+		}
+	}()
+	module.Execute(program)
+	i.loader.loaded[path] = module
+	return module
+}
+
+func (i *Interpreter) module(name string, line int) *Module {
+	module, ok := i.modules[name]
+	if !ok {
+		panic(fmt.Sprintf("Undefined module: %s at line %d", name, line))
+	}
+	return module
+}
+
+// moduleVar reads one of a module's top-level variables.
+func (i *Interpreter) moduleVar(n *Identifier) interface{} {
+	top := i.module(n.Module, n.Line).interpreter.callStack[0]
+	if slot := top.slot(n.Name); slot >= 0 {
+		return top.values[slot]
+	}
+	panic(fmt.Sprintf("Undefined variable: %s.%s", n.Module, n.Name))
+}
+
+// callModuleFunction calls a module's sigma. The arguments are evaluated
+// here, but the body runs in the module, where its own names resolve.
+func (i *Interpreter) callModuleFunction(n *BetaCall) interface{} {
+	module := i.module(n.Module, n.Line).interpreter
+	fn, ok := module.functions[n.Name]
+	if !ok {
+		panic(fmt.Sprintf("Undefined function: %s.%s", n.Module, n.Name))
+	}
+	args := i.evaluateArgs(fn, n)
+	// An error leaving the module must not leave the call on its stack
+	depth := len(module.callStack)
+	caller := module.caller
+	module.caller = i
+	defer func() {
+		module.caller = caller
+		if r := recover(); r != nil {
+			module.unwindTo(depth)
+			panic(r)
+		}
+	}()
+	return module.callFunction(fn, args, n.Line)
+}
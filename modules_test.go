@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestModuleObservers checks that the tracer, profiler and coverage see
+// the code of imported modules, marked with the module's file.
+func TestModuleObservers(t *testing.T) {
+	file := filepath.Join("test", "modules.skibidi")
+	code, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interpreter := NewInterpreter()
+	interpreter.output = io.Discard
+	interpreter.SetFile(file)
+	var trace bytes.Buffer
+	profiler := NewProfiler(file, string(code))
+	coverage := NewCoverage(file, string(code))
+	interpreter.observers = []ExecutionObserver{NewTracer(&trace, interpreter), profiler, coverage}
+	interpreter.Execute(NewParser(NewLexer(string(code))).Parse())
+	profiler.Finish()
+
+	for _, want := range []string{
+		"shapes.skibidi:2 | gyatt \"shapes loaded\" ohio\n",
+		"   5 | gyatt beta shapes.area(3, 4) ohio\n",
+		"shapes.skibidi:6 |   alpha w * h * unit ohio\n",
+	} {
+		if !strings.Contains(trace.String(), want) {
+			t.Errorf("trace is missing %q:\n%s", want, trace.String())
+		}
+	}
+
+	if calls := profiler.functions["shapes.area"].calls; calls != 2 {
+		t.Errorf("profiled %d calls of shapes.area, want 2", calls)
+	}
+	var summary bytes.Buffer
+	profiler.PrintSummary(&summary, -1)
+	if !strings.Contains(summary.String(), "alpha w * h * unit ohio") {
+		t.Errorf("profile summary is missing the module's lines:\n%s", summary.String())
+	}
+
+	var lcov bytes.Buffer
+	if err := coverage.WriteLcov(&lcov); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lcov.String(), "shapes.skibidi\n") || !strings.Contains(lcov.String(), "DA:6,2\n") {
+		t.Errorf("lcov has no record of the module:\n%s", lcov.String())
+	}
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profiler records where a running program spends its time. Each time the
+// interpreter reports a step, the time since the previous step is charged
+// to the line that was running and to the chain of sigma calls above it.
+// Modules the program imports are charged to the same profile, their lines
+// and sigmas marked with the module's file.
+type Profiler struct {
+	filename string
+	lines    []string
+	modules  map[string][]string // source lines of each imported module, by path
+	start    time.Time
+	last     time.Time
+	stack    []*profileFrame // stack[0] is the top level
+
+	functions map[string]*functionProfile
+	lineStats map[sourceLine]*lineProfile
+	samples   map[string]*profileSample
+}
+
+// sourceLine is a line of the program, or of a module when file is set.
+type sourceLine struct {
+	file string
+	line int
+}
+
+type profileFrame struct {
+	name    string
+	at      sourceLine // line running in this frame
+	entered time.Time
+}
+
+type functionProfile struct {
+	name   string
+	file   string // set for a module's sigma
+	line   int
+	calls  int
+	active int // calls of this function in progress, so recursion isn't counted twice
+	total  time.Duration
+	self   time.Duration
+}
+
+type lineProfile struct {
+	sourceLine
+	hits int
+	time time.Duration
+}
+
+// profileSample is one distinct call stack, innermost location first.
+type profileSample struct {
+	stack []profileLocation
+	hits  int
+	time  time.Duration
+}
+
+type profileLocation struct {
+	function string
+	sourceLine
+}
+
+// moduleProfiler charges an imported module's steps to the profiler of the
+// program that imported it.
+type moduleProfiler struct {
+	p    *Profiler
+	file string
+}
+
+func (p *Profiler) forModule(module *Interpreter, path string, code string) ExecutionObserver {
+	p.modules[path] = strings.Split(code, "\n")
+	return &moduleProfiler{p: p, file: path}
+}
+
+func (m *moduleProfiler) forModule(module *Interpreter, path string, code string) ExecutionObserver {
+	return m.p.forModule(module, path, code)
+}
+
+func (m *moduleProfiler) Statement(stmt ASTNode, depth int) {
+	m.p.statement(sourceLine{m.file, nodeLine(stmt)})
+}
+
+func (m *moduleProfiler) Assigned(stmt ASTNode, name string, value interface{}, depth int) {}
+
+func (m *moduleProfiler) Condition(stmt ASTNode, result bool, depth int) {}
+
+// Enter names a module's sigma after its file, so it isn't mixed up with
+// the program's sigma of the same name.
+func (m *moduleProfiler) Enter(fn *SigmaFunc, args []interface{}, depth int) {
+	module := strings.TrimSuffix(filepath.Base(m.file), filepath.Ext(m.file))
+	m.p.enter(module+"."+fn.Name, m.file, fn.Line)
+}
+
+func (m *moduleProfiler) Exit(fn *SigmaFunc, result interface{}, depth int) {
+	m.p.pop(m.p.charge())
+}
+
+func NewProfiler(filename string, code string) *Profiler {
+	now := time.Now()
+	p := &Profiler{
+		filename:  filename,
+		lines:     strings.Split(code, "\n"),
+		modules:   make(map[string][]string),
+		start:     now,
+		last:      now,
+		functions: make(map[string]*functionProfile),
+		lineStats: make(map[sourceLine]*lineProfile),
+		samples:   make(map[string]*profileSample),
+	}
+	p.stack = []*profileFrame{{name: "<main>", entered: now}}
+	p.function("<main>", "", 0).calls = 1
+	return p
+}
+
+func (p *Profiler) function(name string, file string, line int) *functionProfile {
+	fp, ok := p.functions[name]
+	if !ok {
+		fp = &functionProfile{name: name, file: file, line: line}
+		p.functions[name] = fp
+	}
+	return fp
+}
+
+// sample returns the entry for the current call stack.
+func (p *Profiler) sample() *profileSample {
+	var key strings.Builder
+	stack := make([]profileLocation, len(p.stack))
+	for idx, frame := range p.stack {
+		stack[len(p.stack)-1-idx] = profileLocation{frame.name, frame.at}
+		fmt.Fprintf(&key, "%s:%s:%d;", frame.name, frame.at.file, frame.at.line)
+	}
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &profileSample{stack: stack}
+		p.samples[key.String()] = s
+	}
+	return s
+}
+
+// charge gives the time since the last step to whatever was running.
+func (p *Profiler) charge() time.Time {
+	now := time.Now()
+	elapsed := now.Sub(p.last)
+	p.last = now
+
+	top := p.stack[len(p.stack)-1]
+	p.functions[top.name].self += elapsed
+	if top.at.line > 0 {
+		p.lineStats[top.at].time += elapsed
+		p.sample().time += elapsed
+	}
+	return now
+}
+
+func (p *Profiler) Statement(stmt ASTNode, depth int) {
+	p.statement(sourceLine{line: nodeLine(stmt)})
+}
+
+func (p *Profiler) statement(at sourceLine) {
+	p.charge()
+	p.stack[len(p.stack)-1].at = at
+	ls, ok := p.lineStats[at]
+	if !ok {
+		ls = &lineProfile{sourceLine: at}
+		p.lineStats[at] = ls
+	}
+	ls.hits++
+	p.sample().hits++
+}
+
+func (p *Profiler) Assigned(stmt ASTNode, name string, value interface{}, depth int) {}
+
+func (p *Profiler) Condition(stmt ASTNode, result bool, depth int) {}
+
+func (p *Profiler) Enter(fn *SigmaFunc, args []interface{}, depth int) {
+	p.enter(fn.Name, "", fn.Line)
+}
+
+func (p *Profiler) enter(name string, file string, line int) {
+	now := p.charge()
+	fp := p.function(name, file, line)
+	fp.calls++
+	fp.active++
+	p.stack = append(p.stack, &profileFrame{name: name, entered: now})
+}
+
+func (p *Profiler) Exit(fn *SigmaFunc, result interface{}, depth int) {
+	now := p.charge()
+	p.pop(now)
+}
+
+func (p *Profiler) pop(now time.Time) {
+	frame := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	fp := p.functions[frame.name]
+	fp.active--
+	if fp.active == 0 {
+		fp.total += now.Sub(frame.entered)
+	}
+}
+
+// Finish stops the clock, closing any calls an error left open.
+func (p *Profiler) Finish() {
+	now := p.charge()
+	for len(p.stack) > 1 {
+		p.pop(now)
+	}
+	p.functions["<main>"].total = now.Sub(p.start)
+}
+
+// PrintSummary shows the top functions and lines by time.
+func (p *Profiler) PrintSummary(out io.Writer, top int) {
+	functions := make([]*functionProfile, 0, len(p.functions))
+	for _, fp := range p.functions {
+		functions = append(functions, fp)
+	}
+	sort.Slice(functions, func(a, b int) bool {
+		if functions[a].total != functions[b].total {
+			return functions[a].total > functions[b].total
+		}
+		return functions[a].name < functions[b].name
+	})
+
+	lines := make([]*lineProfile, 0, len(p.lineStats))
+	for _, lp := range p.lineStats {
+		lines = append(lines, lp)
+	}
+	sort.Slice(lines, func(a, b int) bool {
+		if lines[a].time != lines[b].time {
+			return lines[a].time > lines[b].time
+		}
+		if lines[a].file != lines[b].file {
+			return lines[a].file < lines[b].file
+		}
+		return lines[a].line < lines[b].line
+	})
+
+	fmt.Fprintf(out, "📊 Profile of %s (%v total)\n", p.filename, p.functions["<main>"].total.Round(time.Microsecond))
+	fmt.Fprintf(out, "%-20s %8s %12s %12s\n", "sigma", "calls", "total", "self")
+	for idx, fp := range functions {
+		if idx == top {
+			break
+		}
+		fmt.Fprintf(out, "%-20s %8d %12v %12v\n", fp.name, fp.calls,
+			fp.total.Round(time.Microsecond), fp.self.Round(time.Microsecond))
+	}
+
+	if len(lines) > top && top >= 0 {
+		lines = lines[:top]
+	}
+	// Module lines are shown with their file, so the column grows to fit
+	locations := make([]string, len(lines))
+	width := 6
+	for idx, lp := range lines {
+		locations[idx] = fmt.Sprint(lp.line)
+		if lp.file != "" {
+			locations[idx] = fmt.Sprintf("%s:%d", filepath.Base(lp.file), lp.line)
+		}
+		if len(locations[idx]) > width {
+			width = len(locations[idx])
+		}
+	}
+	fmt.Fprintf(out, "\n%-*s %8s %12s  %s\n", width, "line", "hits", "time", "source")
+	for idx, lp := range lines {
+		source := p.lines
+		if lp.file != "" {
+			source = p.modules[lp.file]
+		}
+		text := ""
+		if lp.line >= 1 && lp.line <= len(source) {
+			text = strings.TrimSpace(source[lp.line-1])
+		}
+		fmt.Fprintf(out, "%-*s %8d %12v  %s\n", width, locations[idx], lp.hits, lp.time.Round(time.Microsecond), text)
+	}
+}
+
+// WriteFolded writes one line per call stack in the folded format read by
+// flamegraph tools, weighted by nanoseconds.
+func (p *Profiler) WriteFolded(out io.Writer) error {
+	totals := make(map[string]time.Duration)
+	for _, s := range p.samples {
+		names := make([]string, len(s.stack))
+		for idx, loc := range s.stack {
+			names[len(s.stack)-1-idx] = loc.function
+		}
+		totals[strings.Join(names, ";")] += s.time
+	}
+	for _, stack := range sortedKeys(totals) {
+		if _, err := fmt.Fprintf(out, "%s %d\n", stack, totals[stack].Nanoseconds()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePprof writes a gzipped profile.proto that go tool pprof can read.
+// Each sample counts the statements run and the time spent at a stack of
+// (sigma, line) locations.
+func (p *Profiler) WritePprof(out io.Writer) error {
+	var prof protoBuffer
+	strs := map[string]int{"": 0}
+	table := []string{""}
+	str := func(s string) uint64 {
+		if idx, ok := strs[s]; ok {
+			return uint64(idx)
+		}
+		strs[s] = len(table)
+		table = append(table, s)
+		return uint64(strs[s])
+	}
+
+	valueType := func(typ, unit string) []byte {
+		var vt protoBuffer
+		vt.uintField(1, str(typ))
+		vt.uintField(2, str(unit))
+		return vt.Bytes()
+	}
+	prof.bytesField(1, valueType("statements", "count"))
+	prof.bytesField(1, valueType("time", "nanoseconds"))
+
+	// A module's top level runs as part of <main>, so a function is told
+	// apart by the file its lines are in too
+	functionIDs := make(map[profileLocation]uint64)
+	locationIDs := make(map[profileLocation]uint64)
+	var functions, locations [][]byte
+	locationID := func(loc profileLocation) uint64 {
+		if id, ok := locationIDs[loc]; ok {
+			return id
+		}
+		key := profileLocation{function: loc.function, sourceLine: sourceLine{file: loc.file}}
+		fnID, ok := functionIDs[key]
+		if !ok {
+			fnID = uint64(len(functionIDs) + 1)
+			functionIDs[key] = fnID
+			// pprof drops anything in angle brackets from names
+			name := strings.Trim(loc.function, "<>")
+			file, start := p.filename, p.functions[loc.function].line
+			if loc.file != "" {
+				file = loc.file
+			}
+			if p.functions[loc.function].file != loc.file {
+				start = 0
+			}
+			var fn protoBuffer
+			fn.uintField(1, fnID)
+			fn.uintField(2, str(name))
+			fn.uintField(3, str(name))
+			fn.uintField(4, str(file))
+			fn.uintField(5, uint64(start))
+			functions = append(functions, fn.Bytes())
+		}
+		id := uint64(len(locationIDs) + 1)
+		locationIDs[loc] = id
+		var line, location protoBuffer
+		line.uintField(1, fnID)
+		line.uintField(2, uint64(loc.line))
+		location.uintField(1, id)
+		location.bytesField(4, line.Bytes())
+		locations = append(locations, location.Bytes())
+		return id
+	}
+
+	for _, key := range sortedKeys(p.samples) {
+		s := p.samples[key]
+		ids := make([]uint64, len(s.stack))
+		for idx, loc := range s.stack {
+			ids[idx] = locationID(loc)
+		}
+		var sample protoBuffer
+		sample.packedField(1, ids)
+		sample.packedField(2, []uint64{uint64(s.hits), uint64(s.time.Nanoseconds())})
+		prof.bytesField(2, sample.Bytes())
+	}
+	for _, location := range locations {
+		prof.bytesField(4, location)
+	}
+	for _, fn := range functions {
+		prof.bytesField(5, fn)
+	}
+	timeIndex := str("time")
+	period := valueType("time", "nanoseconds")
+	for _, s := range table {
+		prof.bytesField(6, []byte(s))
+	}
+	prof.uintField(9, uint64(p.start.UnixNano()))
+	prof.uintField(10, uint64(p.functions["<main>"].total.Nanoseconds()))
+	prof.bytesField(11, period)
+	prof.uintField(12, 1)
+	prof.uintField(14, timeIndex)
+
+	zw := gzip.NewWriter(out)
+	if _, err := zw.Write(prof.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protoBuffer encodes just enough protobuf for WritePprof.
+type protoBuffer struct {
+	bytes.Buffer
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+func (b *protoBuffer) uintField(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(field) << 3)
+	b.varint(x)
+}
+
+func (b *protoBuffer) bytesField(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
+
+func (b *protoBuffer) packedField(field int, xs []uint64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytesField(field, packed.Bytes())
+}
+
+// writeProfile saves the pprof profile to path and the folded stacks next
+// to it in path.folded.
+func (p *Profiler) writeProfile(path string) error {
+	var pprof, folded bytes.Buffer
+	if err := p.WritePprof(&pprof); err != nil {
+		return err
+	}
+	if err := p.WriteFolded(&folded); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, pprof.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(path+".folded", folded.Bytes(), 0644)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+const profileProgram = `sigma fact(n) {
+    cap (n <= 1) {
+        alpha 1 ohio
+    }
+    alpha n * fact(n - 1) ohio
+}
+gyatt fact(3) ohio
+gyatt "done" ohio
+`
+
+func profileRun(t *testing.T) *Profiler {
+	t.Helper()
+	profiler := NewProfiler("fact.skibidi", profileProgram)
+	runObserved(profileProgram, func(interpreter *Interpreter) {
+		interpreter.observers = append(interpreter.observers, profiler)
+	})
+	profiler.Finish()
+	return profiler
+}
+
+func TestProfilerCounts(t *testing.T) {
+	p := profileRun(t)
+	if calls := p.functions["fact"].calls; calls != 3 {
+		t.Errorf("fact called %d times, want 3", calls)
+	}
+	hits := map[int]int{}
+	for at, lp := range p.lineStats {
+		hits[at.line] = lp.hits
+	}
+	want := map[int]int{1: 1, 2: 3, 3: 1, 5: 2, 7: 1, 8: 1}
+	for line, n := range want {
+		if hits[line] != n {
+			t.Errorf("line %d hit %d times, want %d", line, hits[line], n)
+		}
+	}
+}
+
+// TestProfilerFolded checks the folded stacks, outermost sigma first.
+func TestProfilerFolded(t *testing.T) {
+	var folded bytes.Buffer
+	if err := profileRun(t).WriteFolded(&folded); err != nil {
+		t.Fatal(err)
+	}
+	var stacks []string
+	for _, line := range strings.Split(strings.TrimSpace(folded.String()), "\n") {
+		space := strings.LastIndex(line, " ")
+		if space < 0 || strings.Trim(line[space+1:], "0123456789") != "" {
+			t.Fatalf("bad folded line %q", line)
+		}
+		stacks = append(stacks, line[:space])
+	}
+	want := []string{"<main>", "<main>;fact", "<main>;fact;fact", "<main>;fact;fact;fact"}
+	if strings.Join(stacks, " ") != strings.Join(want, " ") {
+		t.Errorf("got stacks %v, want %v", stacks, want)
+	}
+}
+
+// protoField is one field of a decoded protobuf message.
+type protoField struct {
+	number int
+	value  uint64 // for varints
+	data   []byte // for length-delimited fields
+}
+
+func decodeProto(t *testing.T, data []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatal("bad protobuf key")
+		}
+		data = data[n:]
+		field := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			field.value, n = binary.Uvarint(data)
+			data = data[n:]
+		case 2:
+			size, n := binary.Uvarint(data)
+			data = data[n:]
+			field.data, data = data[:size], data[size:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func decodePacked(data []byte) []uint64 {
+	var xs []uint64
+	for len(data) > 0 {
+		x, n := binary.Uvarint(data)
+		xs = append(xs, x)
+		data = data[n:]
+	}
+	return xs
+}
+
+// TestProfilerPprof decodes the gzipped profile.proto and checks its
+// sample types, names and that the samples count every statement run.
+func TestProfilerPprof(t *testing.T) {
+	p := profileRun(t)
+	var out bytes.Buffer
+	if err := p.WritePprof(&out); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("profile is not gzipped: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var strs []string
+	var samples [][]byte
+	locations := map[uint64]bool{}
+	for _, f := range decodeProto(t, data) {
+		switch f.number {
+		case 2:
+			samples = append(samples, f.data)
+		case 4:
+			locations[decodeProto(t, f.data)[0].value] = true
+		case 6:
+			strs = append(strs, string(f.data))
+		}
+	}
+	if len(strs) == 0 || strs[0] != "" {
+		t.Fatalf("string table must start with \"\": %q", strs)
+	}
+	for _, want := range []string{"statements", "count", "time", "nanoseconds", "main", "fact", "fact.skibidi"} {
+		found := false
+		for _, s := range strs {
+			found = found || s == want
+		}
+		if !found {
+			t.Errorf("string table %q is missing %q", strs, want)
+		}
+	}
+
+	statements := 0
+	for _, sample := range samples {
+		for _, f := range decodeProto(t, sample) {
+			switch f.number {
+			case 1:
+				for _, id := range decodePacked(f.data) {
+					if !locations[id] {
+						t.Errorf("sample refers to missing location %d", id)
+					}
+				}
+			case 2:
+				values := decodePacked(f.data)
+				if len(values) != 2 {
+					t.Fatalf("sample has %d values, want 2", len(values))
+				}
+				statements += int(values[0])
+			}
+		}
+	}
+	if statements != 9 {
+		t.Errorf("samples count %d statements, want 9", statements)
+	}
+}
+
+// TestProfilerSummaryLineZero checks a statement without a line, which
+// has no source to show, doesn't break the summary.
+func TestProfilerSummaryLineZero(t *testing.T) {
+	p := NewProfiler("empty.skibidi", "")
+	p.Statement(&PrintStmt{}, 0)
+	p.Finish()
+
+	var out bytes.Buffer
+	p.PrintSummary(&out, -1)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	last := strings.Fields(lines[len(lines)-1])
+	if len(last) < 2 || last[0] != "0" || last[1] != "1" {
+		t.Errorf("want a row for line 0 with 1 hit, got:\n%s", out.String())
+	}
+}
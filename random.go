@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+func init() {
+	registerBuiltin("random", 0, 0, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return i.random.Float64()
+	})
+	registerBuiltin("randint", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		lo, hi := intArg(name, args, 0), intArg(name, args, 1)
+		if lo > hi {
+			panic(fmt.Sprintf("randint range %d to %d is empty at line %d", lo, hi, line))
+		}
+		return float64(lo + i.random.Intn(hi-lo+1))
+	})
+	registerBuiltin("choice", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		list := listArg(name, args, 0)
+		if len(list.Items) == 0 {
+			panic(fmt.Sprintf("choice from an empty list at line %d", line))
+		}
+		return list.Items[i.random.Intn(len(list.Items))]
+	})
+	// shuffle reorders the list in place, and returns it for convenience
+	registerBuiltin("shuffle", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		list := listArg(name, args, 0)
+		checkMutable(name, list, line)
+		i.random.Shuffle(len(list.Items), func(a, b int) {
+			list.Items[a], list.Items[b] = list.Items[b], list.Items[a]
+		})
+		return list
+	})
+	registerBuiltin("seed", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		i.random.Seed(int64(intArg(name, args, 0)))
+		return nil
+	})
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxHistory = 1000
+
+// LineEditor reads REPL input with cursor movement, history and tab
+// completion when its input is a terminal. Otherwise it reads plain lines,
+// so piped input still works.
+type LineEditor struct {
+	out         io.Writer
+	reader      *bufio.Reader // the only reader of the input, shared with input()
+	rawMode     func() (restore func(), err error)
+	history     []string
+	historyFile string
+	complete    func(word string) []string
+
+	pending []byte // the rest of a line Read has started handing out
+	buf     []rune
+	pos     int
+	prompt  string
+}
+
+// NewLineEditor reads from in and echoes to out. rawMode switches the
+// terminal to raw mode and returns how to switch it back; it is nil when in
+// isn't a terminal. History is loaded from historyFile, if it is set.
+// complete returns the candidates for the word being typed.
+func NewLineEditor(in io.Reader, out io.Writer, rawMode func() (func(), error), historyFile string, complete func(word string) []string) *LineEditor {
+	e := &LineEditor{
+		out:         out,
+		reader:      bufio.NewReader(in),
+		rawMode:     rawMode,
+		historyFile: historyFile,
+		complete:    complete,
+	}
+	if historyFile != "" {
+		if content, err := os.ReadFile(historyFile); err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				if strings.TrimSpace(line) != "" {
+					e.history = append(e.history, line)
+				}
+			}
+		}
+		if len(e.history) > maxHistory {
+			e.history = e.history[len(e.history)-maxHistory:]
+		}
+	}
+	return e
+}
+
+// historyPath is where the REPL keeps its history between sessions.
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".skibidi_history")
+}
+
+// AddHistory remembers a line and appends it to the history file. Piped
+// input isn't recorded.
+func (e *LineEditor) AddHistory(line string) {
+	if e.rawMode == nil || strings.TrimSpace(line) == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[1:]
+	}
+	if e.historyFile == "" {
+		return
+	}
+	file, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// Read hands out the input a line at a time, so a bufio.Scanner reading
+// through the editor for input() never takes what the next REPL entry
+// was going to read.
+func (e *LineEditor) Read(p []byte) (int, error) {
+	if len(e.pending) == 0 {
+		line, err := e.reader.ReadString('\n')
+		if line == "" {
+			return 0, err
+		}
+		e.pending = []byte(line)
+	}
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+// ReadLine shows prompt and returns the line typed, or io.EOF when input
+// ends. Ctrl-C abandons the line and returns an empty one.
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	if e.rawMode != nil {
+		if restore, err := e.rawMode(); err == nil {
+			defer restore()
+			return e.edit(prompt)
+		}
+	}
+
+	fmt.Fprint(e.out, prompt)
+	line, err := e.reader.ReadString('\n')
+	if line == "" && err != nil {
+		return "", io.EOF
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// edit runs the line editor until Enter, Ctrl-C or Ctrl-D.
+func (e *LineEditor) edit(prompt string) (string, error) {
+	e.prompt = prompt
+	e.buf = nil
+	e.pos = 0
+	historyIdx := len(e.history)
+	draft := ""
+	fmt.Fprint(e.out, prompt)
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			fmt.Fprintln(e.out)
+			return "", io.EOF
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprintln(e.out)
+			return string(e.buf), nil
+		case 3: // Ctrl-C
+			fmt.Fprintln(e.out, "^C")
+			return "", nil
+		case 4: // Ctrl-D
+			if len(e.buf) == 0 {
+				fmt.Fprintln(e.out)
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+		case 127, 8: // Backspace
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+		case 1: // Ctrl-A
+			e.pos = 0
+		case 5: // Ctrl-E
+			e.pos = len(e.buf)
+		case 2: // Ctrl-B
+			e.move(-1)
+		case 6: // Ctrl-F
+			e.move(1)
+		case 11: // Ctrl-K
+			e.buf = e.buf[:e.pos]
+		case 21: // Ctrl-U
+			e.buf = e.buf[e.pos:]
+			e.pos = 0
+		case 23: // Ctrl-W
+			start := e.pos
+			for start > 0 && e.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.buf[start-1] != ' ' {
+				start--
+			}
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+		case 12: // Ctrl-L
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case '\t':
+			e.completeWord()
+		case 27: // Escape sequence
+			switch e.readEscape() {
+			case "[A", "OA": // Up
+				if historyIdx > 0 {
+					if historyIdx == len(e.history) {
+						draft = string(e.buf)
+					}
+					historyIdx--
+					e.setLine(e.history[historyIdx])
+				}
+			case "[B", "OB": // Down
+				if historyIdx < len(e.history) {
+					historyIdx++
+					if historyIdx == len(e.history) {
+						e.setLine(draft)
+					} else {
+						e.setLine(e.history[historyIdx])
+					}
+				}
+			case "[C", "OC":
+				e.move(1)
+			case "[D", "OD":
+				e.move(-1)
+			case "[H", "OH", "[1~", "[7~":
+				e.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				e.pos = len(e.buf)
+			case "[3~": // Delete
+				e.deleteAt(e.pos)
+			}
+		default:
+			if r >= ' ' && r != utf8.RuneError {
+				e.buf = append(e.buf[:e.pos], append([]rune{r}, e.buf[e.pos:]...)...)
+				e.pos++
+			}
+		}
+		e.redraw()
+	}
+}
+
+// readEscape reads the rest of an escape sequence such as "[A" or "[3~".
+func (e *LineEditor) readEscape() string {
+	first, err := e.reader.ReadByte()
+	if err != nil || (first != '[' && first != 'O') {
+		return ""
+	}
+	seq := []byte{first}
+	for {
+		b, err := e.reader.ReadByte()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, b)
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || b == '~' {
+			return string(seq)
+		}
+	}
+}
+
+func (e *LineEditor) move(delta int) {
+	e.pos += delta
+	if e.pos < 0 {
+		e.pos = 0
+	}
+	if e.pos > len(e.buf) {
+		e.pos = len(e.buf)
+	}
+}
+
+func (e *LineEditor) deleteAt(pos int) {
+	if pos < len(e.buf) {
+		e.buf = append(e.buf[:pos], e.buf[pos+1:]...)
+	}
+}
+
+func (e *LineEditor) setLine(line string) {
+	e.buf = []rune(line)
+	e.pos = len(e.buf)
+}
+
+// redraw rewrites the prompt and line and puts the cursor back in place.
+func (e *LineEditor) redraw() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// completeWord finishes the word before the cursor. When several names
+// match it fills in what they share, or lists them if they share no more.
+func (e *LineEditor) completeWord() {
+	start := e.pos
+	for start > 0 && isIdentRune(e.buf[start-1]) {
+		start--
+	}
+	word := string(e.buf[start:e.pos])
+	matches := e.complete(word)
+	if len(matches) == 0 {
+		return
+	}
+
+	common := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(matches) == 1 {
+		common += " "
+	}
+	if common != word {
+		insert := []rune(strings.TrimPrefix(common, word))
+		e.buf = append(e.buf[:e.pos], append(insert, e.buf[e.pos:]...)...)
+		e.pos += len(insert)
+		return
+	}
+	fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(matches, "  "))
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// replCompletions returns the keywords, built-ins, variables and sigma
+// functions that start with word.
+func (i *Interpreter) replCompletions(word string) []string {
+	seen := make(map[string]bool)
+	var matches []string
+	add := func(name string) {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			matches = append(matches, name)
+		}
+	}
+	for name := range keywordDocs {
+		add(name)
+	}
+	for name := range builtinArgs {
+		add(name)
+	}
+	for name := range builtinConstants {
+		add(name)
+	}
+	for _, frame := range i.callStack {
+		for _, name := range frame.names {
+			add(name)
+		}
+	}
+	for name := range i.functions {
+		add(name)
+	}
+	sort.Strings(matches)
+	return matches
+}
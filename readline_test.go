@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeTerminal makes the editor treat its input as a terminal and counts
+// how often raw mode is switched on and off.
+type fakeTerminal struct {
+	raw, restored int
+}
+
+func (f *fakeTerminal) rawMode() (func(), error) {
+	f.raw++
+	return func() { f.restored++ }, nil
+}
+
+// editLines types keys into an editor in raw mode and returns the lines
+// it reads until the input ends.
+func editLines(t *testing.T, keys string, history []string, complete func(string) []string) []string {
+	t.Helper()
+	term := &fakeTerminal{}
+	e := NewLineEditor(strings.NewReader(keys), io.Discard, term.rawMode, "", complete)
+	e.history = history
+	var lines []string
+	for {
+		line, err := e.ReadLine("> ")
+		if err == io.EOF {
+			break
+		}
+		lines = append(lines, line)
+	}
+	if term.raw == 0 || term.raw != term.restored {
+		t.Errorf("raw mode switched on %d times and restored %d times", term.raw, term.restored)
+	}
+	return lines
+}
+
+func TestLineEditorKeys(t *testing.T) {
+	tests := []struct {
+		name, keys, want string
+	}{
+		{"typing", "gyatt 1\r", "gyatt 1"},
+		{"left arrow inserts mid-line", "ac\x1b[Db\r", "abc"},
+		{"right arrow", "ac\x1b[D\x1b[Cd\r", "acd"},
+		{"home and end", "bc\x1b[Ha\x1b[Fd\r", "abcd"},
+		{"ctrl-a and ctrl-e", "bc\x01a\x05d\r", "abcd"},
+		{"ctrl-b and ctrl-f", "ac\x02b\x06d\r", "abcd"},
+		{"backspace", "abx\x7fc\r", "abc"},
+		{"backspace at start", "\x7f\x7fa\r", "a"},
+		{"delete", "abxc\x1b[D\x1b[D\x1b[3~\r", "abc"},
+		{"ctrl-d deletes under the cursor", "abxc\x1b[D\x1b[D\x04\r", "abc"},
+		{"ctrl-k", "abcdef\x1b[D\x1b[D\x1b[D\x0b\r", "abc"},
+		{"ctrl-u", "xyzabc\x1b[D\x1b[D\x1b[D\x15\r", "abc"},
+		{"ctrl-w", "gyatt some words\x17\r", "gyatt some "},
+		{"ctrl-w skips spaces", "gyatt some   \x17\r", "gyatt "},
+		{"ctrl-c abandons the line", "abc\x03", ""},
+		{"newline ends the line", "abc\n", "abc"},
+		{"unicode", "héllo\x1b[D\x7f\r", "hélo"},
+	}
+	for _, test := range tests {
+		lines := editLines(t, test.keys, nil, nil)
+		if len(lines) != 1 || lines[0] != test.want {
+			t.Errorf("%s: got %q, want [%q]", test.name, lines, test.want)
+		}
+	}
+
+	if lines := editLines(t, "\x04", nil, nil); len(lines) != 0 {
+		t.Errorf("ctrl-d on an empty line: got %q, want EOF", lines)
+	}
+	if lines := editLines(t, "abc", nil, nil); len(lines) != 0 {
+		t.Errorf("input ending mid-line: got %q, want EOF", lines)
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	history := []string{"first", "second"}
+	tests := []struct {
+		name, keys, want string
+	}{
+		{"up recalls the last line", "\x1b[A\r", "second"},
+		{"up twice", "\x1b[A\x1b[A\r", "first"},
+		{"up stops at the oldest", "\x1b[A\x1b[A\x1b[A\r", "first"},
+		{"down goes back", "\x1b[A\x1b[A\x1b[B\r", "second"},
+		{"down restores the draft", "dra\x1b[A\x1b[Bft\r", "draft"},
+		{"recalled lines can be edited", "\x1b[A\x7f\x7fmething\r", "secomething"},
+		{"application mode arrows", "\x1bOA\r", "second"},
+	}
+	for _, test := range tests {
+		lines := editLines(t, test.keys, history, nil)
+		if len(lines) != 1 || lines[0] != test.want {
+			t.Errorf("%s: got %q, want [%q]", test.name, lines, test.want)
+		}
+	}
+}
+
+func TestLineEditorHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("old\n\n  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	term := &fakeTerminal{}
+	e := NewLineEditor(strings.NewReader(""), io.Discard, term.rawMode, path, nil)
+	for _, line := range []string{"one", "one", " ", "two"} {
+		e.AddHistory(line)
+	}
+	if got, want := strings.Join(e.history, ","), "old,one,two"; got != want {
+		t.Errorf("history = %s, want %s", got, want)
+	}
+
+	// a new session picks up where the last one stopped
+	e = NewLineEditor(strings.NewReader("\x1b[A\x1b[A\r"), io.Discard, term.rawMode, path, nil)
+	if line, err := e.ReadLine("> "); err != nil || line != "one" {
+		t.Errorf("ReadLine = %q, %v; want \"one\"", line, err)
+	}
+
+	// piped input isn't a session worth remembering
+	piped := NewLineEditor(strings.NewReader(""), io.Discard, nil, path, nil)
+	piped.AddHistory("three")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "old\n\n  \none\ntwo\n"; got != want {
+		t.Errorf("history file = %q, want %q", got, want)
+	}
+}
+
+func TestLineEditorHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	var lines []string
+	for n := 0; n < maxHistory+10; n++ {
+		lines = append(lines, "line"+strconv.Itoa(n))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	term := &fakeTerminal{}
+	e := NewLineEditor(strings.NewReader(""), io.Discard, term.rawMode, path, nil)
+	if len(e.history) != maxHistory || e.history[0] != lines[10] {
+		t.Fatalf("loaded %d lines starting with %q, want %d starting with %q", len(e.history), e.history[0], maxHistory, lines[10])
+	}
+	e.AddHistory("newest")
+	if len(e.history) != maxHistory || e.history[0] != lines[11] || e.history[maxHistory-1] != "newest" {
+		t.Errorf("after adding: %d lines from %q to %q", len(e.history), e.history[0], e.history[len(e.history)-1])
+	}
+}
+
+func TestLineEditorCompletion(t *testing.T) {
+	names := []string{"gyatt", "sigma", "split", "sqrt", "str"}
+	complete := func(word string) []string {
+		var matches []string
+		for _, name := range names {
+			if strings.HasPrefix(name, word) {
+				matches = append(matches, name)
+			}
+		}
+		return matches
+	}
+
+	tests := []struct {
+		name, keys, want string
+	}{
+		{"single match adds a space", "gy\t1\r", "gyatt 1"},
+		{"common prefix", "sp\t\r", "split "},
+		{"shared prefix only", "s\t\r", "s"},
+		{"several matches", "sq\tx\r", "sqrt x"},
+		{"word before the cursor", "gyatt st(x)\x1b[D\x1b[D\x1b[D\t\r", "gyatt str (x)"},
+		{"no match", "zz\t\r", "zz"},
+	}
+	for _, test := range tests {
+		lines := editLines(t, test.keys, nil, complete)
+		if len(lines) != 1 || lines[0] != test.want {
+			t.Errorf("%s: got %q, want [%q]", test.name, lines, test.want)
+		}
+	}
+
+	// with nothing left to fill in, Tab lists the candidates
+	var out strings.Builder
+	term := &fakeTerminal{}
+	e := NewLineEditor(strings.NewReader("s\t\r"), &out, term.rawMode, "", complete)
+	if _, err := e.ReadLine("> "); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\r\nsigma  split  sqrt  str\r\n") {
+		t.Errorf("output %q doesn't list the candidates", out.String())
+	}
+}
+
+// TestLineEditorPiped checks that without a terminal the editor reads
+// plain lines, and that input() reading through it takes exactly one.
+func TestLineEditorPiped(t *testing.T) {
+	var out strings.Builder
+	e := NewLineEditor(strings.NewReader("skibidi x rizz input() ohio\r\nanswer\ngyatt x ohio\nlast"), &out, nil, "", nil)
+	input := bufio.NewScanner(e)
+
+	line, err := e.ReadLine("> ")
+	if err != nil || line != "skibidi x rizz input() ohio" {
+		t.Fatalf("first ReadLine = %q, %v", line, err)
+	}
+	if !input.Scan() || input.Text() != "answer" {
+		t.Fatalf("input() read %q, want \"answer\"", input.Text())
+	}
+	for _, want := range []string{"gyatt x ohio", "last"} {
+		if line, err := e.ReadLine("> "); err != nil || line != want {
+			t.Errorf("ReadLine = %q, %v; want %q", line, err, want)
+		}
+	}
+	if _, err := e.ReadLine("> "); err != io.EOF {
+		t.Errorf("ReadLine at the end = %v, want EOF", err)
+	}
+	if input.Scan() {
+		t.Errorf("input() at the end read %q", input.Text())
+	}
+	if got := out.String(); got != "> > > > " {
+		t.Errorf("output = %q, want only prompts", got)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// terminalRawMode returns a function that puts the terminal f in raw mode
+// and returns how to restore it, or nil if f isn't a terminal. It uses
+// stty, which every Unix-like system has.
+func terminalRawMode(f *os.File) func() (func(), error) {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	stty := func(args ...string) (string, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = f
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	return func() (func(), error) {
+		saved, err := stty("-g")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+			return nil, err
+		}
+		return func() { stty(saved) }, nil
+	}
+}
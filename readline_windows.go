@@ -0,0 +1,48 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+// terminalRawMode returns a function that puts the console f in raw mode
+// and returns how to restore it, or nil if f isn't a console. Arrow keys
+// then arrive as the same escape sequences as on other systems, and the
+// console on stdout draws the ones the editor writes.
+func terminalRawMode(f *os.File) func() (func(), error) {
+	in := syscall.Handle(f.Fd())
+	var inMode uint32
+	if syscall.GetConsoleMode(in, &inMode) != nil {
+		return nil
+	}
+	return func() (func(), error) {
+		raw := inMode&^(enableProcessedInput|enableLineInput|enableEchoInput) | enableVirtualTerminalInput
+		if ok, _, err := setConsoleMode.Call(uintptr(in), uintptr(raw)); ok == 0 {
+			return nil, err
+		}
+		out := syscall.Handle(os.Stdout.Fd())
+		var outMode uint32
+		hasOut := syscall.GetConsoleMode(out, &outMode) == nil
+		if hasOut {
+			setConsoleMode.Call(uintptr(out), uintptr(outMode|enableVirtualTerminalProcessing))
+		}
+		return func() {
+			setConsoleMode.Call(uintptr(in), uintptr(inMode))
+			if hasOut {
+				setConsoleMode.Call(uintptr(out), uintptr(outMode))
+			}
+		}, nil
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// REPL holds an interactive session.
+type REPL struct {
+	interpreter *Interpreter
+	editor      *LineEditor
+	session     []string // entries that ran, for :save
+}
+
+func runInteractive() {
+	fmt.Println("🚽 Skibidi Interactive Mode v2.0 🚽")
+	fmt.Println("Type :help for commands. Type 'exit' or :exit to quit.")
+	r := &REPL{interpreter: NewInterpreter()}
+	r.interpreter.interactive = true
+	r.editor = NewLineEditor(os.Stdin, os.Stdout, terminalRawMode(os.Stdin), historyPath(), func(word string) []string {
+		return r.interpreter.replCompletions(word)
+	})
+	// input() reads through the editor, so the two don't split stdin
+	r.interpreter.inputScanner = bufio.NewScanner(r.editor)
+
+	var inputLines []string
+	var pending error // why the lines so far aren't a whole entry yet
+
+	for {
+		prompt := "skibidi> "
+		if len(inputLines) > 0 {
+			prompt = "... "
+		}
+		line, err := r.editor.ReadLine(prompt)
+		if err != nil {
+			fmt.Println("Goodbye! Stay sigma! 🗿")
+			break
+		}
+		r.editor.AddHistory(line)
+		trimmed := strings.TrimSpace(line)
+
+		if len(inputLines) == 0 {
+			if strings.HasPrefix(trimmed, ":") {
+				if r.command(strings.TrimSpace(trimmed[1:])) {
+					return
+				}
+				continue
+			}
+			if trimmed == "exit" {
+				fmt.Println("Goodbye! Stay sigma! 🗿")
+				break
+			}
+			if trimmed == "" {
+				continue
+			}
+		} else if trimmed == "" {
+			// A blank line gives up on an unfinished entry
+			fmt.Printf("Skibidi Error: %v\n", pending)
+			inputLines = nil
+			continue
+		}
+
+		inputLines = append(inputLines, line)
+		input := strings.Join(inputLines, "\n")
+		expr, program, err := parseREPLInput(input)
+		var incomplete *IncompleteInputError
+		if errors.As(err, &incomplete) {
+			pending = err
+			continue
+		}
+		inputLines = nil
+		if err != nil {
+			fmt.Printf("Skibidi Error: %v\n", err)
+			continue
+		}
+		if runREPLInput(r.interpreter, expr, program) {
+			r.remember(input, expr)
+		}
+	}
+}
+
+// remember records an entry that ran so :save can write it out. Bare
+// expressions are only worth keeping when they call something.
+func (r *REPL) remember(input string, expr ASTNode) {
+	if expr == nil {
+		r.session = append(r.session, input)
+	} else if call, ok := expr.(*BetaCall); ok {
+		r.session = append(r.session, formatExpression(call)+" ohio")
+	}
+}
+
+// command runs a :command and reports whether the REPL should exit.
+func (r *REPL) command(command string) bool {
+	name, arg, _ := strings.Cut(command, " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(name) {
+	case "exit", "quit":
+		fmt.Println("Goodbye! Stay sigma! 🗿")
+		return true
+	case "help":
+		r.help()
+	case "vars":
+		r.printVars()
+	case "funcs":
+		fmt.Println("Functions:")
+		for _, fnName := range sortedKeys(r.interpreter.functions) {
+			fmt.Printf("  %s\n", functionSignature(r.interpreter.functions[fnName]))
+		}
+	case "load":
+		r.load(arg)
+	case "save":
+		r.save(arg)
+	case "reset":
+		interpreter := NewInterpreter()
+		interpreter.inputScanner = r.interpreter.inputScanner
+		interpreter.interactive = true
+		r.interpreter = interpreter
+		r.session = nil
+		fmt.Println("🔄 Session reset")
+	case "ast":
+		if expr, program, err := r.parseArg("ast", arg); err == nil {
+			if expr != nil {
+				dumpAST(expr, 0)
+			} else {
+				dumpAST(program, 0)
+			}
+		}
+	case "tokens":
+		r.printTokens(arg)
+	case "time":
+		if expr, program, err := r.parseArg("time", arg); err == nil {
+			r.time(expr, program)
+		}
+	case "type":
+		expr, _, err := r.parseArg("type", arg)
+		if err != nil {
+			break
+		}
+		if expr == nil {
+			fmt.Println("Skibidi Error: :type needs an expression")
+			break
+		}
+		r.evaluate(func() {
+			fmt.Println(typeName(r.interpreter.evaluateExpression(expr)))
+		})
+	default:
+		fmt.Println("Unknown command. Type :help for help.")
+	}
+	return false
+}
+
+// :time runs code again and again until it has used timeBudget, or
+// maxTimeRuns times, and reports the average.
+const (
+	timeBudget  = time.Second
+	maxTimeRuns = 1000
+)
+
+// time runs an entry once as usual, then repeats it silently and prints
+// how long a run takes on average. Each run has its effects, so code that
+// changes a variable changes it every time.
+func (r *REPL) time(expr ASTNode, program *Program) {
+	start := time.Now()
+	if !runREPLInput(r.interpreter, expr, program) {
+		return
+	}
+	elapsed := time.Since(start)
+	runs := 1
+
+	output := r.interpreter.output
+	r.interpreter.output = io.Discard
+	defer func() { r.interpreter.output = output }()
+	for runs < maxTimeRuns && elapsed < timeBudget {
+		start := time.Now()
+		ok := r.evaluate(func() {
+			if expr != nil {
+				r.interpreter.evaluateExpression(expr)
+			} else {
+				r.interpreter.Execute(program)
+			}
+		})
+		if !ok {
+			return
+		}
+		elapsed += time.Since(start)
+		runs++
+	}
+	fmt.Printf("⏱  %v per run (%d runs)\n", elapsed/time.Duration(runs), runs)
+}
+
+func (r *REPL) help() {
+	fmt.Println("Available commands:")
+	fmt.Println("  :help            show this help")
+	fmt.Println("  :vars            list variables, globals first")
+	fmt.Println("  :funcs           list sigma functions")
+	fmt.Println("  :load <file>     run a file in this session")
+	fmt.Println("  :save <file>     write the entries that ran to a file")
+	fmt.Println("  :reset           forget all variables and functions")
+	fmt.Println("  :ast <code>      show the syntax tree for code")
+	fmt.Println("  :tokens <code>   show the tokens in code")
+	fmt.Println("  :time <code>     run code repeatedly and show the average time")
+	fmt.Println("  :type <expr>     show the type of an expression's value")
+	fmt.Println("  :exit            leave the REPL")
+}
+
+// exitOnExitError leaves the REPL if a program called exit.
+func exitOnExitError(r interface{}) {
+	if exit, ok := r.(*ExitError); ok {
+		fmt.Println("Goodbye! Stay sigma! 🗿")
+		os.Exit(exit.Code)
+	}
+}
+
+// parseArg parses the code given to a :command, printing any error.
+func (r *REPL) parseArg(command, code string) (ASTNode, *Program, error) {
+	if code == "" {
+		err := fmt.Errorf("usage: :%s <code>", command)
+		fmt.Printf("Skibidi Error: %v\n", err)
+		return nil, nil, err
+	}
+	expr, program, err := parseREPLInput(code)
+	if err != nil {
+		fmt.Printf("Skibidi Error: %v\n", err)
+	}
+	return expr, program, err
+}
+
+// evaluate runs fn, reporting a runtime error instead of leaving the REPL.
+func (r *REPL) evaluate(fn func()) (ok bool) {
+	depth := len(r.interpreter.callStack)
+	defer func() {
+		if rec := recover(); rec != nil {
+			exitOnExitError(rec)
+			r.interpreter.callStack = r.interpreter.callStack[:depth]
+			fmt.Printf("Skibidi Error: %v\n", rec)
+			ok = false
+		}
+	}()
+	fn()
+	return true
+}
+
+// printVars lists the variables in every frame, globals first.
+func (r *REPL) printVars() {
+	i := r.interpreter
+	fmt.Println("Globals:")
+	for idx, frame := range i.callStack {
+		if idx > 0 {
+			fmt.Printf("Scope %d:\n", idx)
+		}
+		vars := frame.variables()
+		for _, name := range sortedKeys(vars) {
+			fmt.Printf("  %s = %s\n", name, i.toString(vars[name]))
+		}
+	}
+}
+
+func (r *REPL) load(filename string) {
+	if filename == "" {
+		fmt.Println("Skibidi Error: usage: :load <file>")
+		return
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Printf("❌ Error reading file '%s': %v\n", filename, err)
+		return
+	}
+	program, err := parseProgram(string(content))
+	if err != nil {
+		fmt.Printf("Skibidi Error: %v\n", err)
+		return
+	}
+	if runREPLInput(r.interpreter, nil, program) {
+		r.session = append(r.session, strings.TrimRight(string(content), "\n"))
+		fmt.Printf("✅ Loaded %s\n", filename)
+	}
+}
+
+func (r *REPL) save(filename string) {
+	if filename == "" {
+		fmt.Println("Skibidi Error: usage: :save <file>")
+		return
+	}
+	content := strings.Join(r.session, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		fmt.Printf("❌ Error writing file '%s': %v\n", filename, err)
+		return
+	}
+	fmt.Printf("💾 Saved %d entries to %s\n", len(r.session), filename)
+}
+
+func (r *REPL) printTokens(code string) {
+	if code == "" {
+		fmt.Println("Skibidi Error: usage: :tokens <code>")
+		return
+	}
+	lexer := NewLexer(code)
+	for {
+		token := lexer.NextToken()
+		if token.Type == EOF && token.Value == "" {
+			break
+		}
+		fmt.Printf("  %-4d %-13s %q\n", token.Line, token.Type, token.Value)
+	}
+}
+
+// dumpAST prints a node and its children, one per line, indented by depth.
+func dumpAST(node ASTNode, depth int) {
+	pad := strings.Repeat("  ", depth)
+	if node == nil {
+		fmt.Printf("%s<none>\n", pad)
+		return
+	}
+	fmt.Printf("%s%s\n", pad, node)
+	block := func(label string, statements []ASTNode) {
+		fmt.Printf("%s  %s:\n", pad, label)
+		for _, stmt := range statements {
+			dumpAST(stmt, depth+2)
+		}
+	}
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			dumpAST(stmt, depth+1)
+		}
+	case *VarDecl:
+		dumpAST(n.Value, depth+1)
+	case *Assignment:
+		dumpAST(n.Value, depth+1)
+	case *PrintStmt:
+		dumpAST(n.Value, depth+1)
+	case *AlphaReturn:
+		if n.Value != nil {
+			dumpAST(n.Value, depth+1)
+		}
+	case *BinaryOp:
+		dumpAST(n.Left, depth+1)
+		dumpAST(n.Right, depth+1)
+	case *BetaCall:
+		for _, arg := range n.Args {
+			dumpAST(arg, depth+1)
+		}
+	case *ListLiteral:
+		for _, element := range n.Elements {
+			dumpAST(element, depth+1)
+		}
+	case *IndexExpr:
+		dumpAST(n.Target, depth+1)
+		dumpAST(n.Index, depth+1)
+	case *IfStmt:
+		dumpAST(n.Condition, depth+1)
+		block("then", n.ThenBlock)
+		if n.ElseBlock != nil {
+			block("nocap", n.ElseBlock)
+		}
+	case *WhileStmt:
+		dumpAST(n.Condition, depth+1)
+		block("body", n.Body)
+	case *ForStmt:
+		dumpAST(n.Init, depth+1)
+		dumpAST(n.Condition, depth+1)
+		dumpAST(n.Post, depth+1)
+		block("body", n.Body)
+	case *SigmaFunc:
+		fmt.Printf("%s  params: %s\n", pad, strings.Join(n.Params, ", "))
+		block("body", n.Body)
+	case *ThrowStmt:
+		dumpAST(n.Value, depth+1)
+	case *TryStmt:
+		block("body", n.Body)
+		if n.CatchBlock != nil {
+			block("copium ("+n.CatchName+")", n.CatchBlock)
+		}
+		if n.FinallyBlock != nil {
+			block("touchgrass", n.FinallyBlock)
+		}
+	}
+}
+
+// parseREPLInput parses a REPL entry either as a lone expression (with an
+// optional ohio), whose value gets printed, or as statements. If neither
+// works and either attempt ran out of input, the error is an
+// *IncompleteInputError; otherwise it comes from whichever attempt got
+// further.
+func parseREPLInput(input string) (ASTNode, *Program, error) {
+	expr, exprReached, exprErr := tryParse(input, func(p *Parser) ASTNode {
+		node := p.parseExpression()
+		if p.currentToken.Type == OHIO {
+			p.eat(OHIO)
+		}
+		if p.currentToken.Type != EOF || p.currentToken.Value != "" {
+			panic(fmt.Sprintf("Unexpected token %s at line %d", p.currentToken.Value, p.currentToken.Line))
+		}
+		return node
+	})
+	if exprErr == nil {
+		return expr, nil, nil
+	}
+	program, programReached, programErr := tryParse(input, func(p *Parser) ASTNode {
+		return p.Parse()
+	})
+	if programErr == nil {
+		return nil, program.(*Program), nil
+	}
+
+	var incomplete *IncompleteInputError
+	if errors.As(programErr, &incomplete) || errors.As(exprErr, &incomplete) {
+		return nil, nil, incomplete
+	}
+	if exprReached > programReached {
+		return nil, nil, exprErr
+	}
+	return nil, nil, programErr
+}
+
+// tryParse runs parse over input, returning how far into the input it got
+// if it fails.
+func tryParse(input string, parse func(p *Parser) ASTNode) (node ASTNode, reached int, err error) {
+	parser := NewParser(NewLexer(input))
+	defer func() {
+		if r := recover(); r != nil {
+			reached = parser.lexer.position
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	return parse(parser), 0, nil
+}
+
+// runREPLInput evaluates and prints an expression, or runs statements. It
+// reports whether the entry ran without an error.
+func runREPLInput(interpreter *Interpreter, expr ASTNode, program *Program) (ok bool) {
+	// A failed entry can leave scopes pushed, so put the stack back
+	depth := len(interpreter.callStack)
+	defer func() {
+		if r := recover(); r != nil {
+			exitOnExitError(r)
+			interpreter.callStack = interpreter.callStack[:depth]
+			fmt.Printf("Skibidi Error: %v\n", r)
+			ok = false
+		}
+	}()
+
+	if expr != nil {
+		if value := interpreter.evaluateExpression(expr); value != nil {
+			fmt.Println(interpreter.toString(value))
+		}
+		return true
+	}
+	interpreter.Execute(program)
+	return true
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestParseREPLInput checks how an entry is classified: an expression to
+// print, statements to run, input that needs another line, or an error.
+func TestParseREPLInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // "expr", "program", "incomplete" or the error
+	}{
+		{"1 + 2", "expr"},
+		{"1 + 2 ohio", "expr"},
+		{"beta len([1, 2])", "expr"},
+		{"skibidi x rizz 1 ohio", "program"},
+		{"gyatt 1 ohio", "program"},
+		{"x rizz 2 ohio", "program"},
+		{"sigma f() {\n    alpha 1 ohio\n}", "program"},
+		{"sigma f() {", "incomplete"},
+		{"cap (true) {\n    gyatt 1 ohio", "incomplete"},
+		{"skibidi x rizz", "incomplete"},
+		{"1 +", "incomplete"},
+		{"[1, 2", "incomplete"},
+		{"\"unterminated", "incomplete"},
+		{"skibidi x rizz 1", "incomplete"},
+		{"1 2", "Unexpected token 2 at line 1"},
+		{"gyatt ) ohio", "Unexpected token ) at line 1"},
+		{"gyatt 1 ohio\ngyatt ) ohio", "Unexpected token ) at line 2"},
+		{"(1 + 2) 3", "Unexpected token 3 at line 1"},
+	}
+	for _, test := range tests {
+		expr, program, err := parseREPLInput(test.input)
+		var got string
+		var incomplete *IncompleteInputError
+		switch {
+		case errors.As(err, &incomplete):
+			got = "incomplete"
+		case err != nil:
+			got = err.Error()
+		case expr != nil && program == nil:
+			got = "expr"
+		case program != nil && expr == nil:
+			got = "program"
+		}
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestTryParse(t *testing.T) {
+	parseExpr := func(p *Parser) ASTNode { return p.parseExpression() }
+
+	node, reached, err := tryParse("1 + 2", parseExpr)
+	if err != nil || reached != 0 || formatExpression(node) != "1 + 2" {
+		t.Errorf("got %v, %d, %v; want 1 + 2 parsed", node, reached, err)
+	}
+
+	_, short, err := tryParse("1 + )", parseExpr)
+	if err == nil || err.Error() != "Unexpected token ) at line 1" {
+		t.Errorf("got error %v", err)
+	}
+	_, long, _ := tryParse("1 + 2 + 3 + )", parseExpr)
+	if short == 0 || long <= short {
+		t.Errorf("reached %d and %d, want the longer input to get further", short, long)
+	}
+}
+
+// replSession runs REPL entries, handing lines that start with : to the
+// command handler, and returns everything printed.
+func replSession(t *testing.T, r *REPL, entries ...string) string {
+	t.Helper()
+	return captureStdout(t, func() {
+		if r.interpreter == nil {
+			r.interpreter = NewInterpreter()
+			r.interpreter.interactive = true
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry, ":") {
+				r.command(entry[1:])
+				continue
+			}
+			expr, program, err := parseREPLInput(entry)
+			if err != nil {
+				t.Errorf("%q: %v", entry, err)
+				continue
+			}
+			if runREPLInput(r.interpreter, expr, program) {
+				r.remember(entry, expr)
+			}
+		}
+	})
+}
+
+func TestREPLCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    string
+	}{
+		{"type", []string{"skibidi x rizz [1, 2] ohio", ":type x", ":type 1", ":type \"s\"", ":type null"},
+			"list\nnumber\nstring\nnull\n"},
+		{"type errors", []string{":type", ":type skibidi y rizz 1 ohio", ":type missing", ":type 1 +"},
+			"Skibidi Error: usage: :type <code>\n" +
+				"Skibidi Error: :type needs an expression\n" +
+				"Skibidi Error: Undefined variable: missing at line 1\n" +
+				"Skibidi Error: Unexpected end of input at line 1\n"},
+		{"ast", []string{":ast 1 + 2 * 3", ":ast gyatt 1 ohio"},
+			"BinaryOp(+)\n  Number(1.000000)\n  BinaryOp(*)\n    Number(2.000000)\n    Number(3.000000)\n" +
+				"Program\n  PrintStmt\n    Number(1.000000)\n"},
+		{"tokens", []string{":tokens gyatt \"hi\" ohio", ":tokens"},
+			"  1    GYATT         \"gyatt\"\n  1    STRING        \"hi\"\n  1    OHIO          \"ohio\"\n" +
+				"Skibidi Error: usage: :tokens <code>\n"},
+		{"reset", []string{"skibidi x rizz 1 ohio", "sigma f() {\n    alpha 1 ohio\n}", ":reset", ":vars", ":funcs", "x"},
+			"🔄 Session reset\nGlobals:\nFunctions:\nSkibidi Error: Undefined variable: x at line 1\n"},
+		{"unknown", []string{":bogus"}, "Unknown command. Type :help for help.\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := replSession(t, &REPL{}, test.entries...); got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+// TestREPLTime checks that :time shows the first run's output, repeats the
+// entry and reports the average, and stops at an error.
+func TestREPLTime(t *testing.T) {
+	got := replSession(t, &REPL{}, "skibidi n rizz 0 ohio", ":time n rizz n + 1 ohio", ":time gyatt n ohio", "n")
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	runs := regexp.MustCompile(`^⏱  \S+ per run \((\d+) runs\)$`)
+	if len(lines) != 4 || !runs.MatchString(lines[0]) || !runs.MatchString(lines[2]) {
+		t.Fatalf("got:\n%s", got)
+	}
+	// the output of the first run only, and every run counted
+	total := runs.FindStringSubmatch(lines[0])[1]
+	if lines[1] != total || lines[3] != total {
+		t.Errorf("got:\n%s\nwant n to be %s", got, total)
+	}
+
+	got = replSession(t, &REPL{}, ":time missing", ":time 1 / 0")
+	if want := "Skibidi Error: Undefined variable: missing at line 1\n"; !strings.HasPrefix(got, want) || strings.Contains(got, "⏱") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+// TestREPLSaveLoad checks that :save writes the entries that ran, calls
+// among bare expressions included, and :load runs them in a new session.
+func TestREPLSaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.skibidi")
+	r := &REPL{}
+	got := replSession(t, r,
+		"skibidi x rizz [1, 2] ohio",
+		"sigma f(a) {\n    alpha a ohio\n}",
+		"f(3)",
+		"x",
+		"gyatt missing ohio",
+		":save "+file,
+	)
+	want := "3\n[1, 2]\nSkibidi Error: Undefined variable: missing at line 1\n💾 Saved 3 entries to " + file + "\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	saved, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSaved := "skibidi x rizz [1, 2] ohio\nsigma f(a) {\n    alpha a ohio\n}\nf(3) ohio\n"
+	if string(saved) != wantSaved {
+		t.Errorf("saved:\n%s\nwant:\n%s", saved, wantSaved)
+	}
+
+	got = replSession(t, &REPL{}, ":load "+file, ":vars", ":funcs", ":load", ":save")
+	want = "✅ Loaded " + file + "\nGlobals:\n  x = [1, 2]\nFunctions:\n  sigma f(a)\n" +
+		"Skibidi Error: usage: :load <file>\nSkibidi Error: usage: :save <file>\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import "fmt"
+
+// The resolver runs after parsing and works out where each variable a
+// program uses will live, so the interpreter can reach it by position
+// instead of searching every scope by name. Its scopes match the frames the
+// interpreter pushes: the top level, each block, a gyatfor header, a copium
+// block with its error, and a sigma call with its parameters. A name the
+// top level or its blocks use that is never declared is an error before
+// anything runs. Sigma bodies see their own scopes and then the top level.
+// Anything else a sigma uses, such as a local of the block it was defined
+// in or of its caller, is left for the interpreter to find by name when it
+// runs, and a name found nowhere is an error there, where vibecheck can
+// catch it.
+
+// Binding is where the resolver placed a variable: Depth scopes out from
+// the one running, at Slot. Names left unbound are looked up by name when
+// they run.
+type Binding struct {
+	Depth int
+	Slot  int
+	Bound bool
+}
+
+type resolveScope struct {
+	names     []string
+	constants map[string]bool // names declared with deadass
+	parent    *resolveScope
+
+	// sigma marks the parameters of a sigma defined inside a block; the
+	// scopes past it are not the ones around the sigma when it runs
+	sigma bool
+}
+
+func (s *resolveScope) slot(name string) int {
+	for idx, n := range s.names {
+		if n == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// pendingSigma is a sigma body waiting to be resolved, and the scope the
+// sigma was defined in.
+type pendingSigma struct {
+	fn    *SigmaFunc
+	scope *resolveScope
+}
+
+type resolver struct {
+	scope     *resolveScope
+	globals   *resolveScope
+	functions []pendingSigma // bodies to resolve once the top level is done
+	sigmas    map[string]bool
+	inSigma   bool
+
+	// interactive lets a REPL entry declare a global again
+	interactive bool
+}
+
+// resolve binds the variables in program and panics, as a runtime error
+// would, at the first undefined name, redeclaration or change to a deadass.
+// The top level carries on from what earlier programs run by i declared, so
+// REPL entries see each other's variables.
+func (i *Interpreter) resolve(program *Program) {
+	top := i.callStack[0]
+	globals := &resolveScope{names: append([]string(nil), top.names...)}
+	for name := range top.constants {
+		globals.constant(name)
+	}
+	r := &resolver{scope: globals, globals: globals, sigmas: map[string]bool{}, interactive: i.interactive}
+	for name := range i.functions {
+		r.sigmas[name] = true
+	}
+	r.program(program)
+}
+
+// resolveProgram checks a program's names without running it, for the
+// linter and language server.
+func resolveProgram(program *Program) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	globals := &resolveScope{}
+	r := &resolver{scope: globals, globals: globals, sigmas: map[string]bool{}}
+	r.program(program)
+	return nil
+}
+
+func (r *resolver) program(program *Program) {
+	r.collectSigmas(program.Statements)
+	r.statements(program.Statements)
+	// Sigma bodies run after the top level has declared its globals, and
+	// may declare more sigmas of their own
+	r.inSigma = true
+	for idx := 0; idx < len(r.functions); idx++ {
+		fn := r.functions[idx]
+		r.scope = &resolveScope{names: append([]string(nil), fn.fn.Params...), parent: fn.scope}
+		r.scope.sigma = fn.scope != r.globals
+		r.statements(fn.fn.Body)
+	}
+	r.scope = r.globals
+}
+
+// collectSigmas notes every sigma name in the program, nested ones too.
+func (r *resolver) collectSigmas(statements []ASTNode) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *SigmaFunc:
+			r.sigmas[s.Name] = true
+			r.collectSigmas(s.Body)
+		case *IfStmt:
+			r.collectSigmas(s.ThenBlock)
+			r.collectSigmas(s.ElseBlock)
+		case *WhileStmt:
+			r.collectSigmas(s.Body)
+		case *ForStmt:
+			r.collectSigmas(s.Body)
+		case *TryStmt:
+			r.collectSigmas(s.Body)
+			r.collectSigmas(s.CatchBlock)
+			r.collectSigmas(s.FinallyBlock)
+		}
+	}
+}
+
+func (s *resolveScope) constant(name string) {
+	if s.constants == nil {
+		s.constants = make(map[string]bool)
+	}
+	s.constants[name] = true
+}
+
+func (r *resolver) statements(statements []ASTNode) {
+	for _, stmt := range statements {
+		r.statement(stmt)
+	}
+}
+
+// block resolves statements in a scope of their own, which starts out
+// holding names.
+func (r *resolver) block(statements []ASTNode, names ...string) {
+	r.scope = &resolveScope{names: names, parent: r.scope}
+	r.statements(statements)
+	r.scope = r.scope.parent
+}
+
+func (r *resolver) statement(stmt ASTNode) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		r.expression(s.Value)
+		s.Slot = r.declare(s.Name, s.Constant, s.Line)
+	case *Assignment:
+		r.expression(s.Value)
+		s.Var = r.assignment(s.Name, s.Line)
+	case *PrintStmt:
+		r.expression(s.Value)
+	case *IfStmt:
+		r.expression(s.Condition)
+		r.block(s.ThenBlock)
+		if s.ElseBlock != nil {
+			r.block(s.ElseBlock)
+		}
+	case *WhileStmt:
+		r.expression(s.Condition)
+		r.block(s.Body)
+	case *ForStmt:
+		r.scope = &resolveScope{parent: r.scope}
+		if s.Init != nil {
+			r.statement(s.Init)
+		}
+		r.expression(s.Condition)
+		r.block(s.Body)
+		if s.Post != nil {
+			r.statement(s.Post)
+		}
+		r.scope = r.scope.parent
+	case *SigmaFunc:
+		r.functions = append(r.functions, pendingSigma{fn: s, scope: r.scope})
+	case *TryStmt:
+		r.block(s.Body)
+		if s.CatchBlock != nil {
+			r.block(s.CatchBlock, s.CatchName)
+		}
+		if s.FinallyBlock != nil {
+			r.block(s.FinallyBlock)
+		}
+	case *ThrowStmt:
+		r.expression(s.Value)
+	case *BetaCall:
+		r.expression(s)
+	case *AlphaReturn:
+		if s.Value != nil {
+			r.expression(s.Value)
+		}
+	}
+}
+
+func (r *resolver) expression(node ASTNode) {
+	switch n := node.(type) {
+	case *Identifier:
+		if n.Module != "" {
+			return
+		}
+		binding, scope := r.lookup(n.Name)
+		n.Var = binding
+		// pi and e, and sigmas passed by name, are found when the program
+		// runs; the latter still fail there, as they always have. A sigma
+		// may be reading its caller's variables.
+		if _, ok := builtinConstants[n.Name]; ok || scope != nil || r.inSigma || r.sigmas[n.Name] {
+			return
+		}
+		panic(fmt.Sprintf("Undefined variable: %s at line %d", n.Name, n.Line))
+	case *BinaryOp:
+		r.expression(n.Left)
+		r.expression(n.Right)
+	case *ListLiteral:
+		for _, element := range n.Elements {
+			r.expression(element)
+		}
+	case *IndexExpr:
+		r.expression(n.Target)
+		r.expression(n.Index)
+	case *BetaCall:
+		for _, arg := range n.Args {
+			r.expression(arg)
+		}
+	}
+}
+
+// lookup finds name in the scopes visible from the current one. A name
+// from around a sigma defined in a block is left unbound, for the
+// interpreter to find by name.
+func (r *resolver) lookup(name string) (Binding, *resolveScope) {
+	depth := 0
+	bound := true
+	for scope := r.scope; scope != nil; scope = scope.parent {
+		if slot := scope.slot(name); slot >= 0 {
+			if !bound {
+				return Binding{}, scope
+			}
+			return Binding{Depth: depth, Slot: slot, Bound: true}, scope
+		}
+		if scope.sigma {
+			bound = false
+		}
+		depth++
+	}
+	return Binding{}, nil
+}
+
+// declare adds name to the current scope and returns its slot.
+func (r *resolver) declare(name string, constant bool, line int) int {
+	scope := r.scope
+	slot := scope.slot(name)
+	if slot >= 0 {
+		if scope.constants[name] {
+			panic(fmt.Sprintf("Cannot reassign deadass variable %s at line %d", name, line))
+		}
+		if !r.interactive || scope != r.globals {
+			panic(fmt.Sprintf("Cannot redeclare %s in the same scope at line %d", name, line))
+		}
+	} else {
+		slot = len(scope.names)
+		scope.names = append(scope.names, name)
+	}
+	if constant {
+		scope.constant(name)
+	}
+	return slot
+}
+
+func (r *resolver) assignment(name string, line int) Binding {
+	binding, scope := r.lookup(name)
+	switch {
+	case scope == nil && r.inSigma:
+		return Binding{}
+	case scope == nil:
+		panic(fmt.Sprintf("Cannot assign to undeclared variable %s (declare it with skibidi) at line %d", name, line))
+	case scope.constants[name]:
+		panic(fmt.Sprintf("Cannot reassign deadass variable %s at line %d", name, line))
+	}
+	return binding
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// TestResolveBeforeRunning checks that undefined names, redeclarations and
+// changes to a deadass are reported before the first statement runs.
+func TestResolveBeforeRunning(t *testing.T) {
+	tests := []struct{ code, want string }{
+		{"gyatt \"started\" ohio\ngyatt nope ohio\n", "Undefined variable: nope at line 2"},
+		{"gyatt 1 ohio\ntypo rizz 2 ohio\n", "Cannot assign to undeclared variable typo (declare it with skibidi) at line 2"},
+		{"gyatt 1 ohio\ncap (true) {\n    skibidi inner rizz 1 ohio\n}\ngyatt inner ohio\n", "Undefined variable: inner at line 5"},
+		{"gyatt 1 ohio\nvibecheck {\n    gyatt missing ohio\n}\ncopium (e) {\n    gyatt e ohio\n}\n", "Undefined variable: missing at line 3"},
+		{"gyatt 1 ohio\nskibidi x rizz 1 ohio\nskibidi x rizz 2 ohio\n", "Cannot redeclare x in the same scope at line 3"},
+		{"gyatt 1 ohio\nsigma f() {\n    limit rizz 4 ohio\n}\ndeadass limit rizz 3 ohio\n", "Cannot reassign deadass variable limit at line 3"},
+	}
+	for _, test := range tests {
+		out, got := runResolved(test.code)
+		if got != test.want {
+			t.Errorf("%q: error %q, want %q", test.code, got, test.want)
+		}
+		if out != "" {
+			t.Errorf("%q: printed %q before the error", test.code, out)
+		}
+	}
+}
+
+// TestUnboundNames checks that names a sigma body uses but the resolver
+// can't place are still found, or reported, when the sigma runs.
+func TestUnboundNames(t *testing.T) {
+	tests := []struct{ code, out, err string }{
+		{"sigma f() {\n    alpha missing ohio\n}\ngyatt 1 ohio\ngyatt beta f() ohio\n", "1\n", "Undefined variable: missing at line 2"},
+		{"sigma f() {\n    typo rizz 2 ohio\n}\ngyatt 1 ohio\nbeta f() ohio\n", "1\n", "Cannot assign to undeclared variable typo (declare it with skibidi) at line 2"},
+		{"sigma f() {\n    alpha local ohio\n}\nsigma g() {\n    skibidi local rizz 1 ohio\n    alpha beta f() ohio\n}\ngyatt beta g() ohio\n", "1\n", ""},
+		{"cap (true) {\n    skibidi step rizz 2 ohio\n    sigma next(n) {\n        alpha n + step ohio\n    }\n    gyatt beta next(1) ohio\n}\n", "3\n", ""},
+	}
+	for _, test := range tests {
+		out, err := runResolved(test.code)
+		if out != test.out || err != test.err {
+			t.Errorf("%q: printed %q with error %q, want %q with %q", test.code, out, err, test.out, test.err)
+		}
+	}
+}
+
+// TestResolveProgramStrict checks that the linter's resolve reports names
+// that are never declared.
+func TestResolveProgramStrict(t *testing.T) {
+	program := NewParser(NewLexer("gyatt 1 ohio\ngyatt missing ohio\n")).Parse()
+	want := "Undefined variable: missing at line 2"
+	if err := resolveProgram(program); err == nil || err.Error() != want {
+		t.Errorf("resolveProgram error %v, want %q", err, want)
+	}
+}
+
+// runResolved runs code and returns what it printed and the message it
+// failed with, if any.
+func runResolved(code string) (string, string) {
+	var out bytes.Buffer
+	interpreter := NewInterpreter()
+	interpreter.output = &out
+	program := NewParser(NewLexer(code)).Parse()
+	msg := func() (msg string) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = fmt.Sprint(r)
+			}
+		}()
+		interpreter.Execute(program)
+		return ""
+	}()
+	return out.String(), msg
+}
+
+// fibonacciLoop is test/Fibonacci.skibidi run for longer, with the numbers
+// kept small so the loop, not float formatting, is what gets measured.
+const fibonacciLoop = `
+skibidi n rizz 2000 ohio
+skibidi a rizz 0 ohio
+skibidi b rizz 1 ohio
+skibidi i rizz 0 ohio
+bussin (i < n) {
+    skibidi temp rizz b ohio
+    b rizz (a + b) % 1000 ohio
+    a rizz temp ohio
+    i rizz i + 1 ohio
+}
+gyatt a ohio
+`
+
+const fibonacciRecursive = `
+sigma fib(n) {
+    cap (n < 2) {
+        alpha n ohio
+    }
+    alpha beta fib(n - 1) + beta fib(n - 2) ohio
+}
+gyatt beta fib(15) ohio
+`
+
+func benchmarkProgram(b *testing.B, code string) {
+	program := NewParser(NewLexer(code)).Parse()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		interpreter := NewInterpreter()
+		interpreter.output = io.Discard
+		interpreter.Execute(program)
+	}
+}
+
+func BenchmarkFibonacciLoop(b *testing.B) {
+	benchmarkProgram(b, fibonacciLoop)
+}
+
+func BenchmarkFibonacciRecursive(b *testing.B) {
+	benchmarkProgram(b, fibonacciRecursive)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// builtin is a built-in function that takes its arguments already
+// evaluated.
+type builtin struct {
+	min, max int // max is -1 when there is no limit
+	fn       func(i *Interpreter, name string, args []interface{}, line int) interface{}
+}
+
+var builtins = map[string]builtin{}
+
+// registerBuiltin adds a built-in function, and records its argument
+// counts in builtinArgs so the linter and editor tools know about it.
+func registerBuiltin(name string, min, max int, fn func(i *Interpreter, name string, args []interface{}, line int) interface{}) {
+	builtins[name] = builtin{min: min, max: max, fn: fn}
+	builtinArgs[name] = [2]int{min, max}
+}
+
+func (i *Interpreter) callBuiltin(n *BetaCall, b builtin) interface{} {
+	if len(n.Args) < b.min || (b.max >= 0 && len(n.Args) > b.max) {
+		panic(fmt.Sprintf("%s expects %s", n.Name, describeArgCount([2]int{b.min, b.max})))
+	}
+	args := make([]interface{}, len(n.Args))
+	for idx, arg := range n.Args {
+		args[idx] = i.evaluateExpression(arg)
+	}
+	return b.fn(i, n.Name, args, n.Line)
+}
+
+// argError reports an argument of the wrong type, naming its position
+// when the function takes more than one.
+func argError(name string, args []interface{}, idx int, want string) {
+	if len(args) == 1 {
+		panic(fmt.Sprintf("%s expects %s argument", name, want))
+	}
+	panic(fmt.Sprintf("%s expects %s as argument %d", name, want, idx+1))
+}
+
+func stringArg(name string, args []interface{}, idx int) string {
+	s, ok := args[idx].(string)
+	if !ok {
+		argError(name, args, idx, "a string")
+	}
+	return s
+}
+
+func numberArg(name string, args []interface{}, idx int) float64 {
+	n, ok := args[idx].(float64)
+	if !ok {
+		argError(name, args, idx, "a number")
+	}
+	return n
+}
+
+// intArg accepts only whole numbers, for counts and positions.
+func intArg(name string, args []interface{}, idx int) int {
+	n, ok := args[idx].(float64)
+	if !ok || n != math.Trunc(n) {
+		argError(name, args, idx, "a whole number")
+	}
+	return int(n)
+}
+
+func listArg(name string, args []interface{}, idx int) *List {
+	l, ok := args[idx].(*List)
+	if !ok {
+		argError(name, args, idx, "a list")
+	}
+	return l
+}
+
+func init() {
+	registerBuiltin("len", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		switch v := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v))
+		case *List:
+			return float64(len(v.Items))
+		case *Map:
+			return float64(len(v.Keys))
+		}
+		panic("len expects a string, list or map argument")
+	})
+	registerBuiltin("abs", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return math.Abs(i.toFloat(args[0]))
+	})
+	registerBuiltin("str", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return i.toString(args[0])
+	})
+	registerBuiltin("assert", 1, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		if !i.toBool(args[0]) {
+			msg := "assertion failed"
+			if len(args) == 2 {
+				msg += ": " + i.toString(args[1])
+			}
+			panic(&AssertionError{Message: msg, Line: line})
+		}
+		return true
+	})
+	registerBuiltin("assert_eq", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		if !i.equals(args[0], args[1]) {
+			panic(&AssertionError{
+				Message: fmt.Sprintf("assert_eq failed: %s != %s", i.toString(args[0]), i.toString(args[1])),
+				Line:    line,
+			})
+		}
+		return true
+	})
+	registerBuiltin("upper", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return strings.ToUpper(stringArg(name, args, 0))
+	})
+	registerBuiltin("lower", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return strings.ToLower(stringArg(name, args, 0))
+	})
+	registerBuiltin("trim", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return strings.TrimSpace(stringArg(name, args, 0))
+	})
+	registerBuiltin("split", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		// An empty separator splits into characters
+		parts := strings.Split(stringArg(name, args, 0), stringArg(name, args, 1))
+		items := make([]interface{}, len(parts))
+		for idx, part := range parts {
+			items[idx] = part
+		}
+		return &List{Items: items}
+	})
+	registerBuiltin("join", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		list := listArg(name, args, 0)
+		parts := make([]string, len(list.Items))
+		for idx, item := range list.Items {
+			parts[idx] = i.toString(item)
+		}
+		return strings.Join(parts, stringArg(name, args, 1))
+	})
+	registerBuiltin("replace", 3, 3, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return strings.ReplaceAll(stringArg(name, args, 0), stringArg(name, args, 1), stringArg(name, args, 2))
+	})
+	registerBuiltin("contains", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		if list, ok := args[0].(*List); ok {
+			return i.indexOf(list, args[1]) >= 0
+		}
+		return strings.Contains(stringArg(name, args, 0), stringArg(name, args, 1))
+	})
+	registerBuiltin("starts_with", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return strings.HasPrefix(stringArg(name, args, 0), stringArg(name, args, 1))
+	})
+	registerBuiltin("ends_with", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return strings.HasSuffix(stringArg(name, args, 0), stringArg(name, args, 1))
+	})
+	registerBuiltin("index_of", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		if list, ok := args[0].(*List); ok {
+			return float64(i.indexOf(list, args[1]))
+		}
+		s := stringArg(name, args, 0)
+		at := strings.Index(s, stringArg(name, args, 1))
+		if at < 0 {
+			return float64(-1)
+		}
+		return float64(utf8.RuneCountInString(s[:at]))
+	})
+	registerBuiltin("substr", 2, 3, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		runes := []rune(stringArg(name, args, 0))
+		start := intArg(name, args, 1)
+		if start < 0 || start > len(runes) {
+			panic(fmt.Sprintf("substr start %d out of range for string of length %d at line %d", start, len(runes), line))
+		}
+		end := len(runes)
+		if len(args) == 3 {
+			length := intArg(name, args, 2)
+			if length < 0 {
+				panic(fmt.Sprintf("substr length must not be negative at line %d", line))
+			}
+			if start+length < end {
+				end = start + length
+			}
+		}
+		return string(runes[start:end])
+	})
+	registerBuiltin("repeat", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		count := intArg(name, args, 1)
+		if count < 0 {
+			panic(fmt.Sprintf("repeat count must not be negative at line %d", line))
+		}
+		return strings.Repeat(stringArg(name, args, 0), count)
+	})
+	registerBuiltin("char_at", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		return i.index(stringArg(name, args, 0), args[1], line)
+	})
+	registerBuiltin("ord", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		s := stringArg(name, args, 0)
+		if utf8.RuneCountInString(s) != 1 {
+			panic(fmt.Sprintf("ord expects a single character, got %q at line %d", s, line))
+		}
+		r, _ := utf8.DecodeRuneInString(s)
+		return float64(r)
+	})
+	registerBuiltin("chr", 1, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		code := intArg(name, args, 0)
+		if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+			panic(fmt.Sprintf("chr: %d is not a valid character code at line %d", code, line))
+		}
+		return string(rune(code))
+	})
+}
+
+// indexOf finds the first item of list equal to value, or -1.
+func (i *Interpreter) indexOf(list *List, value interface{}) int {
+	for idx, item := range list.Items {
+		if i.equals(item, value) {
+			return idx
+		}
+	}
+	return -1
+}
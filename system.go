@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExitError is raised by exit() to unwind the program. The top level stops
+// quietly and uses Code as the process's exit status.
+type ExitError struct {
+	Code int
+	Line int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit(%d) called at line %d", e.Code, e.Line)
+}
+
+// AllowEnv lets programs read and set environment variables: all of them
+// when names is empty, otherwise the comma-separated names given.
+func (p *Permissions) AllowEnv(names string) {
+	if names == "" {
+		p.allEnv = true
+		return
+	}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			p.env = append(p.env, name)
+		}
+	}
+}
+
+func (i *Interpreter) checkEnv(name, variable string, line int) {
+	if i.permissions.allEnv {
+		return
+	}
+	for _, allowed := range i.permissions.env {
+		if allowed == variable {
+			return
+		}
+	}
+	panic(fmt.Sprintf("Permission denied: %s(%q) needs --allow-env at line %d", name, variable, line))
+}
+
+func init() {
+	registerBuiltin("args", 0, 0, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		items := make([]interface{}, len(i.scriptArgs))
+		for idx, arg := range i.scriptArgs {
+			items[idx] = arg
+		}
+		return &List{Items: items}
+	})
+	// env returns "" for an unset variable, or the default if one is given
+	registerBuiltin("env", 1, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		variable := stringArg(name, args, 0)
+		i.checkEnv(name, variable, line)
+		if value, ok := os.LookupEnv(variable); ok {
+			return value
+		}
+		if len(args) == 2 {
+			return args[1]
+		}
+		return ""
+	})
+	registerBuiltin("set_env", 2, 2, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		variable := stringArg(name, args, 0)
+		i.checkEnv(name, variable, line)
+		if err := os.Setenv(variable, i.toString(args[1])); err != nil {
+			panic(fmt.Sprintf("set_env(%q) failed: %v at line %d", variable, err, line))
+		}
+		return nil
+	})
+	registerBuiltin("exit", 0, 1, func(i *Interpreter, name string, args []interface{}, line int) interface{} {
+		code := 0
+		if len(args) == 1 {
+			code = intArg(name, args, 0)
+		}
+		panic(&ExitError{Code: code, Line: line})
+	})
+}
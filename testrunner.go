@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// testFailure records where a test went wrong so it can be reported as
+// file:line once the whole run is over.
+type testFailure struct {
+	File    string
+	Name    string
+	Line    int
+	Message string
+}
+
+// findTestFiles returns every .skibidi file under path, or path itself when
+// it names a single file.
+func findTestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".skibidi") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// findTests returns the top-level sigma functions whose names start with test_.
+func findTests(program *Program) []*SigmaFunc {
+	var tests []*SigmaFunc
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*SigmaFunc); ok && strings.HasPrefix(fn.Name, "test_") {
+			tests = append(tests, fn)
+		}
+	}
+	return tests
+}
+
+func parseProgram(code string) (program *Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	lexer := NewLexer(code)
+	parser := NewParser(lexer)
+	return parser.Parse(), nil
+}
+
+// runTestFunc runs the file's top-level statements in a fresh interpreter and
+// then calls fn, returning a failure if anything panicked along the way.
+func runTestFunc(file string, program *Program, fn *SigmaFunc, output io.Writer) (failure *testFailure) {
+	interpreter := NewInterpreter()
+	defer func() {
+		if r := recover(); r != nil {
+			failure = &testFailure{File: file, Name: fn.Name, Line: fn.Line, Message: fmt.Sprintf("%v", r)}
+			// Point at the statement that failed, which may be in a helper,
+			// rather than at the test
+			if assertErr, ok := r.(*AssertionError); ok {
+				failure.Line = assertErr.Line
+				failure.Message = assertErr.Message
+			} else if line := errorLine(failure.Message); line > 0 {
+				failure.Line = line
+			} else if interpreter.line > 0 {
+				failure.Line = interpreter.line
+			}
+		}
+	}()
+
+	if len(fn.Params) != 0 {
+		panic(fmt.Sprintf("Test %s must not take arguments", fn.Name))
+	}
+
+	interpreter.output = output
+	interpreter.SetFile(file)
+	interpreter.Execute(program)
+	interpreter.evaluateExpression(&BetaCall{Name: fn.Name, Line: fn.Line})
+	return nil
+}
+
+// runTests runs every test_ function found under path and reports the
+// results. It returns false if any test failed.
+func runTests(path string, verbose bool) bool {
+	files, err := findTestFiles(path)
+	if err != nil {
+		fmt.Printf("❌ Error reading tests from '%s': %v\n", path, err)
+		return false
+	}
+
+	output := io.Discard
+	if verbose {
+		output = os.Stdout
+	}
+
+	passed := 0
+	var failures []*testFailure
+
+	fmt.Printf("🧪 Running Skibidi tests in %s\n", path)
+	fmt.Println("" + strings.Repeat("=", 40))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			failures = append(failures, &testFailure{File: file, Message: err.Error()})
+			fmt.Printf("❌ %s: %v\n", file, err)
+			continue
+		}
+		program, err := parseProgram(string(content))
+		if err != nil {
+			failures = append(failures, &testFailure{File: file, Message: err.Error()})
+			fmt.Printf("❌ %s: %v\n", file, err)
+			continue
+		}
+
+		tests := findTests(program)
+		if len(tests) == 0 {
+			continue
+		}
+
+		fmt.Printf("=== %s\n", file)
+		for _, fn := range tests {
+			if failure := runTestFunc(file, program, fn, output); failure != nil {
+				failures = append(failures, failure)
+				fmt.Printf("  ❌ %s\n", fn.Name)
+				fmt.Printf("     %s:%d: %s\n", failure.File, failure.Line, failure.Message)
+			} else {
+				passed++
+				fmt.Printf("  ✅ %s\n", fn.Name)
+			}
+		}
+	}
+	fmt.Println("" + strings.Repeat("=", 40))
+
+	if len(failures) > 0 {
+		fmt.Printf("❌ %d passed, %d failed\n", passed, len(failures))
+		return false
+	}
+	if passed == 0 {
+		fmt.Println("🤔 No tests found (name your functions test_something)")
+		return true
+	}
+	fmt.Printf("✅ %d passed, 0 failed\n", passed)
+	return true
+}
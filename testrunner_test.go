@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestTree writes files, keyed by path relative to a new directory,
+// and returns the directory.
+func writeTestTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const runnerTests = `sigma helper(n) {
+    alpha n / 0 ohio
+}
+sigma add(a, b) {
+    alpha a + b ohio
+}
+sigma test_add() {
+    assert_eq(add(1, 2), 3) ohio
+}
+sigma test_helper() {
+    gyatt "about to divide" ohio
+    helper(1) ohio
+}
+sigma test_wrong() {
+    assert_eq(add(1, 1), 3) ohio
+}
+sigma test_index() {
+    skibidi items rizz [1] ohio
+    gyatt items[5] ohio
+}
+sigma not_a_test() {
+    alpha 1 ohio
+}
+`
+
+func TestFindTests(t *testing.T) {
+	dir := writeTestTree(t, map[string]string{
+		"a.skibidi":        runnerTests,
+		"sub/b.skibidi":    "sigma test_true() {\n    assert(true) ohio\n}\n",
+		"notes.txt":        "not a program",
+		"sub/c.skibidi.md": "not a program either",
+	})
+	files, err := findTestFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.skibidi"), filepath.Join(dir, "sub", "b.skibidi")}
+	if strings.Join(files, " ") != strings.Join(want, " ") {
+		t.Errorf("found %v, want %v", files, want)
+	}
+
+	single, err := findTestFiles(want[1])
+	if err != nil || len(single) != 1 || single[0] != want[1] {
+		t.Errorf("a single file gave %v, %v", single, err)
+	}
+
+	program, err := parseProgram(runnerTests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fn := range findTests(program) {
+		names = append(names, fn.Name)
+	}
+	if got := strings.Join(names, " "); got != "test_add test_helper test_wrong test_index" {
+		t.Errorf("found tests %s", got)
+	}
+}
+
+// TestRunTests checks the report: a line per test, where each failure
+// happened, the totals, and that failures make the run fail.
+func TestRunTests(t *testing.T) {
+	dir := writeTestTree(t, map[string]string{
+		"a.skibidi": runnerTests,
+		"b.skibidi": "sigma test_true() {\n    assert(true) ohio\n}\n",
+		"c.skibidi": "gyatt \"no tests here\" ohio\n",
+	})
+	a := filepath.Join(dir, "a.skibidi")
+
+	var ok bool
+	out := captureStdout(t, func() {
+		ok = runTests(dir, false)
+	})
+	if ok {
+		t.Error("runTests succeeded with failing tests")
+	}
+	want := "=== " + a + `
+  ✅ test_add
+  ❌ test_helper
+     ` + a + `:2: Division by zero
+  ❌ test_wrong
+     ` + a + `:15: assert_eq failed: 2 != 3
+  ❌ test_index
+     ` + a + `:19: Index 5 out of range for list of length 1 at line 19
+=== ` + filepath.Join(dir, "b.skibidi") + `
+  ✅ test_true
+========================================
+❌ 2 passed, 3 failed
+`
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("got:\n%s\nwant it to end with:\n%s", out, want)
+	}
+	if strings.Contains(out, "about to divide") {
+		t.Error("test output was shown without -v")
+	}
+
+	out = captureStdout(t, func() {
+		ok = runTests(filepath.Join(dir, "b.skibidi"), true)
+	})
+	if !ok || !strings.HasSuffix(out, "✅ 1 passed, 0 failed\n") {
+		t.Errorf("passing run gave ok=%v:\n%s", ok, out)
+	}
+
+	out = captureStdout(t, func() {
+		ok = runTests(filepath.Join(dir, "c.skibidi"), false)
+	})
+	if !ok || !strings.Contains(out, "No tests found") {
+		t.Errorf("run without tests gave ok=%v:\n%s", ok, out)
+	}
+}
+
+// TestTestCommandExitStatus runs `skibidi test` in a child process and
+// checks it exits non-zero only when a test fails.
+func TestTestCommandExitStatus(t *testing.T) {
+	if dir := os.Getenv("SKIBIDI_TEST_DIR"); dir != "" {
+		os.Args = []string{"skibidi", "test", dir}
+		main()
+		return
+	}
+
+	dir := writeTestTree(t, map[string]string{
+		"pass/a.skibidi": "sigma test_true() {\n    assert(true) ohio\n}\n",
+		"fail/a.skibidi": "sigma test_false() {\n    assert(false) ohio\n}\n",
+	})
+	for _, test := range []struct {
+		dir  string
+		code int
+	}{
+		{"pass", 0},
+		{"fail", 1},
+	} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestTestCommandExitStatus$")
+		cmd.Env = append(os.Environ(), "SKIBIDI_TEST_DIR="+filepath.Join(dir, test.dir))
+		err := cmd.Run()
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if code != test.code {
+			t.Errorf("%s: exit status %d, want %d", test.dir, code, test.code)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Tracer logs each step of a running program, indented by call depth.
+type Tracer struct {
+	out         io.Writer
+	interpreter *Interpreter
+	file        string // set for an imported module, whose lines are shown with it
+}
+
+func NewTracer(out io.Writer, interpreter *Interpreter) *Tracer {
+	return &Tracer{out: out, interpreter: interpreter}
+}
+
+func (t *Tracer) forModule(module *Interpreter, path string, code string) ExecutionObserver {
+	return &Tracer{out: t.out, interpreter: module, file: filepath.Base(path)}
+}
+
+func (t *Tracer) log(line int, depth int, format string, args ...interface{}) {
+	location := fmt.Sprintf("%4d", line)
+	if t.file != "" {
+		location = fmt.Sprintf("%s:%d", t.file, line)
+	}
+	fmt.Fprintf(t.out, "%s | %s%s\n", location, strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+// value shows strings quoted so they can be told apart from numbers.
+func (t *Tracer) value(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return quoteString(s)
+	}
+	return t.interpreter.toString(v)
+}
+
+func (t *Tracer) Statement(stmt ASTNode, depth int) {
+	t.log(nodeLine(stmt), depth, "%s", statementHeader(stmt))
+}
+
+func (t *Tracer) Assigned(stmt ASTNode, name string, value interface{}, depth int) {
+	t.log(nodeLine(stmt), depth, "  %s = %s", name, t.value(value))
+}
+
+func (t *Tracer) Condition(stmt ASTNode, result bool, depth int) {
+	t.log(nodeLine(stmt), depth, "  condition is %v", result)
+}
+
+func (t *Tracer) Enter(fn *SigmaFunc, args []interface{}, depth int) {
+	params := make([]string, len(args))
+	for idx, arg := range args {
+		params[idx] = fn.Params[idx] + " = " + t.value(arg)
+	}
+	t.log(fn.Line, depth-1, "→ %s(%s)", fn.Name, strings.Join(params, ", "))
+}
+
+func (t *Tracer) Exit(fn *SigmaFunc, result interface{}, depth int) {
+	t.log(fn.Line, depth-1, "← %s returned %s", fn.Name, t.value(result))
+}
+
+// statementHeader renders a statement on one line, leaving out the bodies
+// of blocks.
+func statementHeader(stmt ASTNode) string {
+	switch s := stmt.(type) {
+	case *IfStmt:
+		return "cap (" + formatExpression(s.Condition) + ")"
+	case *WhileStmt:
+		return "bussin (" + formatExpression(s.Condition) + ")"
+	case *ForStmt:
+		return fmt.Sprintf("gyatfor (%s; %s; %s)",
+			formatSimpleStatement(s.Init), formatExpression(s.Condition), formatSimpleStatement(s.Post))
+	case *SigmaFunc:
+		return fmt.Sprintf("sigma %s(%s)", s.Name, strings.Join(s.Params, ", "))
+	case *TryStmt:
+		return "vibecheck"
+	}
+	f := &Formatter{}
+	f.formatStatement(stmt)
+	return f.lines[0]
+}
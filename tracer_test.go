@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// runObserved runs code with its output discarded, after attach has added
+// observers to the interpreter.
+func runObserved(code string, attach func(interpreter *Interpreter)) {
+	interpreter := NewInterpreter()
+	interpreter.output = io.Discard
+	attach(interpreter)
+	interpreter.Execute(NewParser(NewLexer(code)).Parse())
+}
+
+func TestTracer(t *testing.T) {
+	code := `sigma double(n) {
+    alpha n * 2 ohio
+}
+skibidi name rizz "x" ohio
+gyatfor (skibidi i rizz 0; i < 2; i rizz i + 1) {
+    cap (i == 1) {
+        gyatt beta double(i) ohio
+    }
+}
+`
+	var trace bytes.Buffer
+	runObserved(code, func(interpreter *Interpreter) {
+		interpreter.observers = append(interpreter.observers, NewTracer(&trace, interpreter))
+	})
+
+	want := `   1 | sigma double(n)
+   4 | skibidi name rizz "x" ohio
+   4 |   name = "x"
+   5 | gyatfor (skibidi i rizz 0; i < 2; i rizz i + 1)
+   5 |   i = 0
+   5 |   condition is true
+   6 | cap (i == 1)
+   6 |   condition is false
+   5 |   i = 1
+   5 |   condition is true
+   6 | cap (i == 1)
+   6 |   condition is true
+   7 | gyatt beta double(i) ohio
+   1 | → double(n = 1)
+   2 |   alpha n * 2 ohio
+   1 | ← double returned 2
+   5 |   i = 2
+   5 |   condition is false
+`
+	if trace.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", trace.String(), want)
+	}
+}
+
+// TestTracerNesting checks that each call indents the steps inside it.
+func TestTracerNesting(t *testing.T) {
+	code := `sigma fact(n) {
+    cap (n <= 1) {
+        alpha [n] ohio
+    }
+    alpha fact(n - 1) ohio
+}
+fact(2) ohio
+`
+	var trace bytes.Buffer
+	runObserved(code, func(interpreter *Interpreter) {
+		interpreter.observers = append(interpreter.observers, NewTracer(&trace, interpreter))
+	})
+
+	want := `   1 | sigma fact(n)
+   7 | fact(2) ohio
+   1 | → fact(n = 2)
+   2 |   cap (n <= 1)
+   2 |     condition is false
+   5 |   alpha fact(n - 1) ohio
+   1 |   → fact(n = 1)
+   2 |     cap (n <= 1)
+   2 |       condition is true
+   3 |     alpha [n] ohio
+   1 |   ← fact returned [1]
+   1 | ← fact returned [1]
+`
+	if trace.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", trace.String(), want)
+	}
+}